@@ -18,16 +18,15 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
-	"os/signal"
 	"time"
 
 	"github.com/caarlos0/env/v6"
 	"github.com/spf13/cobra"
+	"github.com/thestormforge/optimize-go/pkg/api"
 	applications "github.com/thestormforge/optimize-go/pkg/api/applications/v2"
 	experiments "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
 	"github.com/thestormforge/optimize-go/pkg/command"
@@ -35,6 +34,9 @@ import (
 	"golang.org/x/oauth2"
 )
 
+// output is the format used to render results.
+var output string
+
 func main() {
 	cfg := &config.Config{}
 
@@ -45,12 +47,20 @@ func main() {
 			if err := env.Parse(cfg); err != nil {
 				return err
 			}
+			if err := cfg.Validate(); err != nil {
+				return err
+			}
+
+			output = command.OutputFormat(cfg, output)
 
-			http.DefaultTransport = cfg.Transport(cmd.Context(), http.DefaultTransport)
+			// Apply the timeouts before the transport is wrapped for authorization
+			http.DefaultTransport = cfg.Transport(cmd.Context(), api.ConfigureTransport(http.DefaultTransport))
 			return nil
 		},
 	}
 
+	cmd.PersistentFlags().StringVarP(&output, "output", "o", "", "output `format`; one of: json, yaml, ndjson")
+
 	// Aggregate the CREATE commands
 	createCmd := &cobra.Command{
 		Use: "create",
@@ -59,6 +69,7 @@ func main() {
 	createCmd.AddCommand(
 		command.NewCreateApplicationCommand(cfg, &printer{format: `created application %q.`}),
 		command.NewCreateScenarioCommand(cfg, &printer{format: `created scenario %q.`}),
+		command.NewCreateExperimentCommand(cfg, &printer{format: `created experiment %q.`}),
 		command.NewCreateTrialCommand(cfg, &printer{format: `created trial %q.`}),
 	)
 
@@ -85,7 +96,9 @@ func main() {
 		command.NewGetScenariosCommand(cfg, &printer{}),
 		command.NewGetRecommendationsCommand(cfg, &printer{}),
 		command.NewGetExperimentsCommand(cfg, &printer{}),
+		command.NewGetParametersCommand(cfg, &printer{}),
 		command.NewGetTrialsCommand(cfg, &printer{}),
+		command.NewGetBestTrialCommand(cfg, &printer{}),
 		command.NewGetClustersCommand(cfg, &printer{}),
 		command.NewGetActivityCommand(cfg, &printer{}),
 	)
@@ -103,6 +116,24 @@ func main() {
 		command.NewDeleteClustersCommand(cfg, &printer{format: `deleted cluster %q.`}),
 	)
 
+	// Aggregate the LABEL commands
+	labelCmd := &cobra.Command{
+		Use: "label",
+	}
+
+	labelCmd.AddCommand(
+		command.NewLabelExperimentsCommand(cfg, &printer{format: `labeled experiment %q.`}),
+	)
+
+	// Aggregate the REPORT commands
+	reportCmd := &cobra.Command{
+		Use: "report",
+	}
+
+	reportCmd.AddCommand(
+		command.NewReportTrialsCommand(cfg, &printer{format: `reported trial %q.`}),
+	)
+
 	// Aggregate the ENABLE commands
 	enableCmd := &cobra.Command{
 		Use: "enable",
@@ -130,28 +161,38 @@ func main() {
 		command.NewWatchActivityCommand(cfg),
 	)
 
+	// Aggregate the CONFIG commands
+	configCmd := &cobra.Command{
+		Use: "config",
+	}
+
+	configCmd.AddCommand(
+		command.NewViewConfigCommand(cfg, &printer{}),
+	)
+
 	// Add the aggregate commends to the root
 	cmd.AddCommand(
 		createCmd,
 		editCmd,
 		getCmd,
 		deleteCmd,
+		labelCmd,
+		reportCmd,
 		enableCmd,
 		watchCmd,
+		configCmd,
 		command.NewWhoAmICommand(cfg),
+		command.NewCheckCommand(cfg),
 	)
 
 	// Create a context for the command
-	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
-	ctx = context.WithValue(ctx, oauth2.HTTPClient, &http.Client{
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{
 		Transport: http.DefaultTransport,
 		Timeout:   10 * time.Second,
 	})
 
-	// Run the command
-	err := cmd.ExecuteContext(ctx)
-	cancel()
-	if err != nil {
+	// Run the command, interrupts cancel the context
+	if err := command.ExecuteContext(ctx, cmd); err != nil {
 		os.Exit(1)
 	}
 }
@@ -181,8 +222,23 @@ func (p *printer) Fprint(w io.Writer, obj interface{}) error {
 		return err
 	}
 
-	enc := json.NewEncoder(w)
-	enc.SetEscapeHTML(false)
-	enc.SetIndent("", "  ")
-	return enc.Encode(obj)
+	pp, err := command.NewPrinter(output)
+	if err != nil {
+		return err
+	}
+	return pp.Fprint(w, obj)
+}
+
+// Streaming indicates if the printer for the current output format renders list
+// items individually as they are produced.
+func (p *printer) Streaming() bool {
+	if p.format != "" {
+		return false
+	}
+
+	pp, err := command.NewPrinter(output)
+	if err != nil {
+		return false
+	}
+	return command.IsStreaming(pp)
 }
@@ -0,0 +1,105 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thestormforge/optimize-go/pkg/command"
+	"github.com/thestormforge/optimize-go/pkg/config"
+)
+
+func TestPrinter_Streaming(t *testing.T) {
+	var srv *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/experiments/fixture", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Link", fmt.Sprintf("<%s/v1/experiments/fixture/trials>; rel=https://stormforge.io/rel/trials", srv.URL))
+		_, _ = fmt.Fprint(w, `{"metrics": [{"name": "cost", "minimize": true}]}`)
+	})
+	mux.HandleFunc("/v1/experiments/fixture/trials", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"trials": [
+  {"number": 1, "status": "completed", "values": [{"metricName": "cost", "value": 20}]},
+  {"number": 2, "status": "completed", "values": [{"metricName": "cost", "value": 10}]}
+]}`)
+	})
+	srv = httptest.NewServer(mux)
+	defer srv.Close()
+
+	defer func(format string) { output = format }(output)
+
+	cases := []struct {
+		desc      string
+		format    string
+		args      []string
+		streaming bool
+		lines     int
+		err       string
+	}{
+		{
+			desc:      "ndjson",
+			format:    "ndjson",
+			args:      []string{"fixture"},
+			streaming: true,
+			lines:     2,
+		},
+		{
+			desc:      "ndjson sorted",
+			format:    "ndjson",
+			args:      []string{"fixture", "--sort-by", "status"},
+			streaming: true,
+			err:       "sorting is not supported with streaming output",
+		},
+		{
+			desc:   "json sorted",
+			format: "json",
+			args:   []string{"fixture", "--sort-by", "status"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			output = c.format
+			p := &printer{}
+			assert.Equal(t, c.streaming, p.Streaming())
+
+			var out bytes.Buffer
+			cmd := command.NewGetTrialsCommand(&config.Config{Server: srv.URL + "/"}, p)
+			cmd.SetArgs(c.args)
+			cmd.SetOut(&out)
+			cmd.SetErr(io.Discard)
+			err := cmd.ExecuteContext(context.Background())
+			if c.err != "" {
+				assert.EqualError(t, err, c.err)
+			} else if assert.NoError(t, err) && c.lines > 0 {
+				assert.Len(t, strings.Split(strings.TrimSpace(out.String()), "\n"), c.lines)
+			}
+		})
+	}
+
+	// Printers with an explicit message format never stream
+	output = "ndjson"
+	assert.False(t, (&printer{format: `got trial %q.`}).Streaming())
+}
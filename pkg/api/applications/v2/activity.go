@@ -17,6 +17,7 @@ limitations under the License.
 package v2
 
 import (
+	"fmt"
 	"net/url"
 	"strings"
 	"time"
@@ -57,6 +58,15 @@ func (ai *ActivityItem) HasTag(tag string) bool {
 	return false
 }
 
+// Timestamp returns the time the activity occurred. The publication date is used
+// if available, otherwise the modification date.
+func (ai *ActivityItem) Timestamp() time.Time {
+	if !ai.DatePublished.IsZero() {
+		return ai.DatePublished
+	}
+	return ai.DateModified
+}
+
 const (
 	TagRun     string = "run"
 	TagScan    string = "scan"
@@ -64,10 +74,72 @@ const (
 	TagRefresh string = "refresh"
 )
 
+// ActivityKind identifies the type of activity an item represents.
+type ActivityKind string
+
+const (
+	ActivityKindUnknown ActivityKind = ""
+	ActivityKindRun     ActivityKind = ActivityKind(TagRun)
+	ActivityKindScan    ActivityKind = ActivityKind(TagScan)
+	ActivityKindApprove ActivityKind = ActivityKind(TagApprove)
+	ActivityKindRefresh ActivityKind = ActivityKind(TagRefresh)
+)
+
+// ParseActivityKind returns the kind of activity associated with a tag.
+func ParseActivityKind(tag string) ActivityKind {
+	switch strings.ToLower(tag) {
+	case TagRun:
+		return ActivityKindRun
+	case TagScan:
+		return ActivityKindScan
+	case TagApprove:
+		return ActivityKindApprove
+	case TagRefresh:
+		return ActivityKindRefresh
+	default:
+		return ActivityKindUnknown
+	}
+}
+
+// Kind returns the kind of activity based on the first recognized tag.
+func (ai *ActivityItem) Kind() ActivityKind {
+	for _, t := range ai.Tags {
+		if k := ParseActivityKind(t); k != ActivityKindUnknown {
+			return k
+		}
+	}
+	return ActivityKindUnknown
+}
+
 type ActivityExtension struct {
+	Status ActivityStatus `json:"status,omitempty"`
 	ActivityFailure
 }
 
+// ActivityStatus represents the progress of an activity.
+type ActivityStatus string
+
+const (
+	ActivityStatusPending   ActivityStatus = "pending"
+	ActivityStatusRunning   ActivityStatus = "running"
+	ActivityStatusCompleted ActivityStatus = "completed"
+	ActivityStatusFailed    ActivityStatus = "failed"
+)
+
+// Done returns true if the activity has reached a terminal state. An activity
+// with a failure reason is always considered done.
+func (ai *ActivityItem) Done() bool {
+	if ai.StormForge == nil {
+		return false
+	}
+	switch ai.StormForge.Status {
+	case ActivityStatusCompleted, ActivityStatusFailed:
+		return true
+	default:
+		return ai.StormForge.FailureReason != ""
+	}
+}
+
 type ActivityFeedQuery struct {
 	Query map[string][]string
 }
@@ -79,6 +151,41 @@ func (q *ActivityFeedQuery) SetType(t ...string) {
 	url.Values(q.Query).Set("type", strings.Join(t, ","))
 }
 
+// SetDateRange restricts the feed to activity published at or after `from` and before `to`.
+// Either bound may be zero to leave that end of the range open.
+func (q *ActivityFeedQuery) SetDateRange(from, to time.Time) {
+	if q.Query == nil {
+		q.Query = make(map[string][]string)
+	}
+	for key, t := range map[string]time.Time{"from": from, "to": to} {
+		if t.IsZero() {
+			url.Values(q.Query).Del(key)
+		} else {
+			url.Values(q.Query).Set(key, t.UTC().Format(time.RFC3339))
+		}
+	}
+}
+
+// Filter removes items from the feed which fall outside the date range of the query. This
+// can be used when the server does not support filtering by date.
+func (q *ActivityFeedQuery) Filter(items []ActivityItem) []ActivityItem {
+	from, _ := time.Parse(time.RFC3339, url.Values(q.Query).Get("from"))
+	to, _ := time.Parse(time.RFC3339, url.Values(q.Query).Get("to"))
+	if from.IsZero() && to.IsZero() {
+		return items
+	}
+
+	result := items[:0]
+	for i := range items {
+		ts := items[i].Timestamp()
+		if (!from.IsZero() && ts.Before(from)) || (!to.IsZero() && !ts.Before(to)) {
+			continue
+		}
+		result = append(result, items[i])
+	}
+	return result
+}
+
 type Activity struct {
 	api.Metadata `json:"-"`
 	Run          *RunActivity     `json:"run,omitempty"`
@@ -89,11 +196,17 @@ type Activity struct {
 
 type RunActivity struct {
 	Scenario string `json:"scenario"`
+	// Optional limit on how long the run should take.
+	Duration api.Duration `json:"duration,omitempty"`
+	// Optional number of trials the run should perform.
+	Budget int64 `json:"budget,omitempty"`
 	ActivityFailure
 }
 
 type ScanActivity struct {
 	Scenario string `json:"scenario"`
+	// Optional limit on how many levels of resources should be discovered.
+	Depth int `json:"depth,omitempty"`
 	ActivityFailure
 }
 
@@ -107,6 +220,53 @@ type RefreshActivity struct {
 	ActivityFailure
 }
 
+// Validate checks that the activity describes exactly one complete request.
+func (a *Activity) Validate() error {
+	var kinds []string
+	if a.Run != nil {
+		kinds = append(kinds, TagRun)
+		if a.Run.Scenario == "" {
+			return fmt.Errorf("run activity requires a scenario")
+		}
+		if a.Run.Duration < 0 {
+			return fmt.Errorf("run activity duration must not be negative")
+		}
+		if a.Run.Budget < 0 {
+			return fmt.Errorf("run activity budget must not be negative")
+		}
+	}
+	if a.Scan != nil {
+		kinds = append(kinds, TagScan)
+		if a.Scan.Scenario == "" {
+			return fmt.Errorf("scan activity requires a scenario")
+		}
+		if a.Scan.Depth < 0 {
+			return fmt.Errorf("scan activity depth must not be negative")
+		}
+	}
+	if a.Approve != nil {
+		kinds = append(kinds, TagApprove)
+		if a.Approve.Recommendation == "" {
+			return fmt.Errorf("approve activity requires a recommendation")
+		}
+	}
+	if a.Refresh != nil {
+		kinds = append(kinds, TagRefresh)
+		if a.Refresh.Application == "" {
+			return fmt.Errorf("refresh activity requires an application")
+		}
+	}
+
+	switch len(kinds) {
+	case 0:
+		return fmt.Errorf("activity type is required")
+	case 1:
+		return nil
+	default:
+		return fmt.Errorf("only one activity type is allowed, got: %s", strings.Join(kinds, ", "))
+	}
+}
+
 type ActivityFailure struct {
 	FailureReason  string `json:"failure_reason,omitempty"`
 	FailureMessage string `json:"failure_message,omitempty"`
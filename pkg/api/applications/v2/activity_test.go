@@ -17,9 +17,17 @@ limitations under the License.
 package v2
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/thestormforge/optimize-go/pkg/api"
 )
 
 func TestActivityFeed_SetBaseURL(t *testing.T) {
@@ -109,3 +117,172 @@ func TestActivityFeed_SetBaseURL(t *testing.T) {
 		})
 	}
 }
+
+func TestActivityItem_Kind(t *testing.T) {
+	cases := []struct {
+		desc     string
+		tags     []string
+		expected ActivityKind
+	}{
+		{
+			desc:     "no tags",
+			expected: ActivityKindUnknown,
+		},
+		{
+			desc:     "scan",
+			tags:     []string{TagScan},
+			expected: ActivityKindScan,
+		},
+		{
+			desc:     "run",
+			tags:     []string{"RUN"},
+			expected: ActivityKindRun,
+		},
+		{
+			desc:     "unrecognized tags first",
+			tags:     []string{"foo", TagApprove},
+			expected: ActivityKindApprove,
+		},
+		{
+			desc:     "multiple kinds",
+			tags:     []string{TagRefresh, TagScan},
+			expected: ActivityKindRefresh,
+		},
+		{
+			desc:     "unrecognized",
+			tags:     []string{"foo", "bar"},
+			expected: ActivityKindUnknown,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			ai := &ActivityItem{Tags: c.tags}
+			assert.Equal(t, c.expected, ai.Kind())
+		})
+	}
+}
+
+func TestActivity_MarshalJSON(t *testing.T) {
+	cases := []struct {
+		desc     string
+		activity Activity
+		expected string
+	}{
+		{
+			desc:     "run",
+			activity: Activity{Run: &RunActivity{Scenario: "https://example.com/scenarios/1"}},
+			expected: `{"run":{"scenario":"https://example.com/scenarios/1"}}`,
+		},
+		{
+			desc: "run options",
+			activity: Activity{Run: &RunActivity{
+				Scenario: "https://example.com/scenarios/1",
+				Duration: api.Duration(90 * time.Minute),
+				Budget:   40,
+			}},
+			expected: `{"run":{"scenario":"https://example.com/scenarios/1","duration":"1h30m0s","budget":40}}`,
+		},
+		{
+			desc:     "scan",
+			activity: Activity{Scan: &ScanActivity{Scenario: "https://example.com/scenarios/1"}},
+			expected: `{"scan":{"scenario":"https://example.com/scenarios/1"}}`,
+		},
+		{
+			desc:     "scan options",
+			activity: Activity{Scan: &ScanActivity{Scenario: "https://example.com/scenarios/1", Depth: 2}},
+			expected: `{"scan":{"scenario":"https://example.com/scenarios/1","depth":2}}`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			assert.NoError(t, c.activity.Validate())
+			b, err := json.Marshal(&c.activity)
+			if assert.NoError(t, err) {
+				assert.JSONEq(t, c.expected, string(b))
+			}
+		})
+	}
+}
+
+func TestActivity_Validate(t *testing.T) {
+	cases := []struct {
+		desc     string
+		activity Activity
+	}{
+		{
+			desc: "empty",
+		},
+		{
+			desc:     "missing scenario",
+			activity: Activity{Run: &RunActivity{Budget: 10}},
+		},
+		{
+			desc:     "negative depth",
+			activity: Activity{Scan: &ScanActivity{Scenario: "https://example.com/scenarios/1", Depth: -1}},
+		},
+		{
+			desc: "multiple types",
+			activity: Activity{
+				Run:  &RunActivity{Scenario: "https://example.com/scenarios/1"},
+				Scan: &ScanActivity{Scenario: "https://example.com/scenarios/1"},
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			assert.Error(t, c.activity.Validate())
+		})
+	}
+}
+
+func TestActivityFeedQuery_SetDateRange(t *testing.T) {
+	from := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2022, 3, 2, 0, 0, 0, 0, time.UTC)
+
+	q := ActivityFeedQuery{}
+	q.SetType(TagScan)
+	q.SetDateRange(from, to)
+	assert.Equal(t, url.Values{
+		"type": {"scan"},
+		"from": {"2022-03-01T00:00:00Z"},
+		"to":   {"2022-03-02T00:00:00Z"},
+	}, url.Values(q.Query))
+
+	q.SetDateRange(from, time.Time{})
+	assert.Equal(t, url.Values{
+		"type": {"scan"},
+		"from": {"2022-03-01T00:00:00Z"},
+	}, url.Values(q.Query))
+}
+
+func TestHTTPAPI_ListActivity_DateRange(t *testing.T) {
+	// The server ignores the date range, the items must still be filtered
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "2022-03-01T00:00:00Z", r.URL.Query().Get("from"))
+		assert.Equal(t, "2022-03-02T00:00:00Z", r.URL.Query().Get("to"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"items": [
+  {"id": "1", "date_published": "2022-02-28T23:59:59Z"},
+  {"id": "2", "date_published": "2022-03-01T00:00:00Z"},
+  {"id": "3", "date_modified": "2022-03-01T12:00:00Z"},
+  {"id": "4", "date_published": "2022-03-02T00:00:00Z"}
+]}`)
+	}))
+	defer srv.Close()
+
+	client, err := api.NewClient(srv.URL, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	q := ActivityFeedQuery{}
+	q.SetDateRange(time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2022, 3, 2, 0, 0, 0, 0, time.UTC))
+	feed, err := NewAPI(client).ListActivity(context.Background(), srv.URL+"/v2/activity/", q)
+	if assert.NoError(t, err) {
+		var ids []string
+		for _, item := range feed.Items {
+			ids = append(ids, item.ID)
+		}
+		assert.Equal(t, []string{"2", "3"}, ids)
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 
 	"github.com/thestormforge/optimize-go/pkg/api"
+	experiments "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
 )
 
 const (
@@ -30,8 +31,11 @@ const (
 	ErrScenarioNotFound       api.ErrorType = "scenario-not-found"
 	ErrScenarioExists         api.ErrorType = "scenario-exists"
 	ErrScanInvalid            api.ErrorType = "scan-invalid"
+	ErrTemplateNotFound       api.ErrorType = "template-not-found"
+	ErrTemplateNotReady       api.ErrorType = "template-not-ready"
 	ErrActivityInvalid        api.ErrorType = "activity-invalid"
 	ErrActivityRateLimited    api.ErrorType = "activity-rate-limited"
+	ErrActivityNotFound       api.ErrorType = "activity-not-found"
 	ErrRecommendationInvalid  api.ErrorType = "recommendation-invalid"
 	ErrRecommendationNotFound api.ErrorType = "recommendation-not-found"
 	ErrClusterNotFound        api.ErrorType = "cluster-not-found"
@@ -63,6 +67,8 @@ type API interface {
 	UpdateApplication(ctx context.Context, u string, app Application) (api.Metadata, error)
 	// UpdateApplicationByName updates or creates an application.
 	UpdateApplicationByName(ctx context.Context, n ApplicationName, app Application) (api.Metadata, error)
+	// PatchApplication updates only the specified attributes of an application.
+	PatchApplication(ctx context.Context, u string, patch ApplicationPatch) (Application, error)
 	// DeleteApplication deletes an application.
 	DeleteApplication(ctx context.Context, u string) error
 
@@ -84,6 +90,11 @@ type API interface {
 	DeleteScenario(ctx context.Context, u string) error
 	// PatchScenario updates attributes on a scenario.
 	PatchScenario(ctx context.Context, u string, scn Scenario) error
+	// GetScenarioExperiments returns an experiments API bound to the scenario along with the current list of experiments.
+	GetScenarioExperiments(ctx context.Context, scn Scenario) (experiments.API, experiments.ExperimentList, error)
+	// ExperimentsAPI returns an experiments API bound to the supplied experiments link, the same
+	// instance is returned for repeated calls with the same link.
+	ExperimentsAPI(u string) (experiments.API, error)
 
 	// GetTemplate gets the application scenario template.
 	GetTemplate(ctx context.Context, u string) (Template, error)
@@ -94,6 +105,8 @@ type API interface {
 
 	// ListActivity gets activity feed for an application.
 	ListActivity(ctx context.Context, u string, q ActivityFeedQuery) (ActivityFeed, error)
+	// GetActivity retrieves a single activity item.
+	GetActivity(ctx context.Context, u string) (ActivityItem, error)
 	// CreateActivity creates application activity.
 	CreateActivity(ctx context.Context, u string, a Activity) error
 	// DeleteActivity resolves application activity.
@@ -119,6 +132,8 @@ type API interface {
 	GetClusterByName(ctx context.Context, n ClusterName) (Cluster, error)
 	// ListClusters lists clusters.
 	ListClusters(ctx context.Context, q ClusterListQuery) (ClusterList, error)
+	// ListClustersByPage returns single page of clusters identified by the supplied URL.
+	ListClustersByPage(ctx context.Context, u string) (ClusterList, error)
 	// PatchCluster updates a cluster title.
 	PatchCluster(ctx context.Context, u string, c ClusterTitle) error
 	// DeleteCluster deletes a cluster.
@@ -199,7 +199,7 @@ func runTest(t *testing.T, td *apitest.ApplicationTestDefinition, appAPI applica
 		var okScan, okRun bool
 		for ai := range activity {
 			// NOTE: We limited the activity types when we subscribed
-			assert.True(t, ai.HasTag(applications.TagScan) || ai.HasTag(applications.TagRun), "unexpected item tag")
+			assert.Contains(t, []applications.ActivityKind{applications.ActivityKindScan, applications.ActivityKindRun}, ai.Kind(), "unexpected item kind")
 
 			// Both scan and run use the external URL to point at the scenario, ignore activity not from this test
 			// NOTE: The subscription will time out if the activities we requested do not show up
@@ -218,9 +218,9 @@ func runTest(t *testing.T, td *apitest.ApplicationTestDefinition, appAPI applica
 			app, err := appAPI.GetApplication(ctx, scn.Link(api.RelationUp))
 			require.NoError(t, err, "failed to retrieve scenario application")
 
-			switch {
+			switch ai.Kind() {
 
-			case ai.HasTag(applications.TagScan):
+			case applications.ActivityKindScan:
 				okScan = t.Run("Handle Scan Activity", func(t *testing.T) {
 					err = appAPI.UpdateTemplate(ctx, scn.Link(api.RelationTemplate), td.GenerateTemplate())
 					require.NoError(t, err, "failed to update template")
@@ -230,16 +230,17 @@ func runTest(t *testing.T, td *apitest.ApplicationTestDefinition, appAPI applica
 					require.NoError(t, err, "failed to acknowledge scan activity")
 				})
 
-			case ai.HasTag(applications.TagRun):
+			case applications.ActivityKindRun:
 				okRun = t.Run("Handle Run Activity", func(t *testing.T) {
 					exp := td.Experiment
 					exp.DisplayName = ai.Title
 
-					// Normally we would reconcile changes between exp and the template, not necessary for the test
-					_, err = appAPI.GetTemplate(ctx, scn.Link(api.RelationTemplate))
+					// Reconcile changes between the experiment and the template
+					tmpl, err := appAPI.GetTemplate(ctx, scn.Link(api.RelationTemplate))
 					require.NoError(t, err, "failed to retrieve scenario template")
+					exp = experiments.ApplyTemplate(tmpl.ExperimentParameters(), tmpl.ExperimentMetrics(), exp)
 
-					expAPI, err := experiments.NewAPIWithEndpoint(client, scn.Link(api.RelationExperiments))
+					expAPI, _, err := appAPI.GetScenarioExperiments(ctx, scn)
 					require.NoError(t, err, "failed to create experiment API for application")
 
 					expName := experiments.ExperimentName(fmt.Sprintf("%s-%s", scn.Name, randomSuffix()))
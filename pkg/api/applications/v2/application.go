@@ -33,6 +33,15 @@ type Application struct {
 // NOTE: Use `DisplayName` as the field since `Title()` is a function on the embedded `Metadata`.
 var _ = Application{}.Title()
 
+// ApplicationPatch is a partial application used to update an application using JSON
+// merge-patch semantics, only the fields which are set will be modified.
+type ApplicationPatch struct {
+	// The display name of the application.
+	DisplayName *string `json:"title,omitempty"`
+	// The resources to replace on the application.
+	Resources []Resource `json:"resources,omitempty"`
+}
+
 type ApplicationListQuery struct{ api.IndexQuery }
 
 type ApplicationItem struct {
@@ -17,8 +17,11 @@ limitations under the License.
 package v2
 
 import (
+	"context"
 	"encoding/json"
+	"io"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -80,3 +83,48 @@ func TestApplicationList_UnmarshalJSON(t *testing.T) {
 		assert.Equal(t, "Test2", l.Applications[1].Title())
 	}
 }
+
+func TestHTTPAPI_PatchApplication(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPatch, r.Method)
+		assert.Equal(t, "application/merge-patch+json", r.Header.Get("Content-Type"))
+
+		switch r.URL.Path {
+		case "/v2/applications/test":
+			body, err := io.ReadAll(r.Body)
+			if assert.NoError(t, err) {
+				assert.JSONEq(t, `{"title":"Test Application"}`, string(body))
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"name":"test","title":"Test Application","resources":[{"kubernetes":{"namespace":"default"}}]}`))
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"not found"}`))
+		}
+	}))
+	defer srv.Close()
+
+	client, err := api.NewClient(srv.URL, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	appAPI := NewAPI(client)
+
+	title := "Test Application"
+	app, err := appAPI.PatchApplication(context.Background(), client.URL("/v2/applications/test").String(), ApplicationPatch{
+		DisplayName: &title,
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Test Application", app.DisplayName)
+		assert.Len(t, app.Resources, 1)
+	}
+
+	_, err = appAPI.PatchApplication(context.Background(), client.URL("/v2/applications/missing").String(), ApplicationPatch{
+		DisplayName: &title,
+	})
+	var apiErr *api.Error
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, ErrApplicationNotFound, apiErr.Type)
+	}
+}
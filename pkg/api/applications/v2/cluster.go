@@ -57,6 +57,27 @@ func (q *ClusterListQuery) SetModules(modules ...ClusterModule) {
 	}
 }
 
+// SetOptimizeProVersion filters the clusters to those running the specified version of Optimize Pro.
+func (q *ClusterListQuery) SetOptimizeProVersion(version string) {
+	if q.IndexQuery == nil {
+		q.IndexQuery = api.IndexQuery{}
+	}
+	if version != "" {
+		url.Values(q.IndexQuery).Set("optimizeProVersion", version)
+	} else {
+		url.Values(q.IndexQuery).Del("optimizeProVersion")
+	}
+}
+
+// Matches checks the supplied cluster against the filters of this query which
+// may not be supported by the server.
+func (q *ClusterListQuery) Matches(item *ClusterItem) bool {
+	if v := url.Values(q.IndexQuery).Get("optimizeProVersion"); v != "" && item.OptimizeProVersion != v {
+		return false
+	}
+	return true
+}
+
 type ClusterItem struct {
 	Cluster
 }
@@ -0,0 +1,79 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thestormforge/optimize-go/pkg/api"
+)
+
+func TestClusterListQuery_SetOptimizeProVersion(t *testing.T) {
+	q := ClusterListQuery{}
+	q.SetModules(ClusterScenarios)
+	q.SetOptimizeProVersion("2.0.0")
+	assert.Equal(t, "modules=scenarios&optimizeProVersion=2.0.0", url.Values(q.IndexQuery).Encode())
+
+	assert.True(t, q.Matches(&ClusterItem{Cluster: Cluster{OptimizeProVersion: "2.0.0"}}))
+	assert.False(t, q.Matches(&ClusterItem{Cluster: Cluster{OptimizeProVersion: "1.0.0"}}))
+
+	q.SetOptimizeProVersion("")
+	assert.Equal(t, "modules=scenarios", url.Values(q.IndexQuery).Encode())
+	assert.True(t, q.Matches(&ClusterItem{Cluster: Cluster{OptimizeProVersion: "1.0.0"}}))
+}
+
+func TestLister_ForEachCluster(t *testing.T) {
+	// The server ignores the version filter, forcing the lister to filter on the client
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("offset") {
+		case "":
+			assert.Equal(t, "2.0.0", r.URL.Query().Get("optimizeProVersion"))
+			w.Header().Set("Link", fmt.Sprintf("<%s?offset=2>; rel=next", r.URL.Path))
+			_, _ = fmt.Fprint(w, `{"items": [{"name": "a", "optimizeProVersion": "2.0.0"}, {"name": "b", "optimizeProVersion": "1.0.0"}]}`)
+		case "2":
+			_, _ = fmt.Fprint(w, `{"items": [{"name": "c", "optimizeProVersion": "2.0.0"}]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	client, err := api.NewClient(srv.URL, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	l := Lister{API: NewAPI(client)}
+	q := ClusterListQuery{}
+	q.SetOptimizeProVersion("2.0.0")
+
+	var names []string
+	err = l.ForEachCluster(context.Background(), q, func(item *ClusterItem) error {
+		names = append(names, item.Name.String())
+		return nil
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"a", "c"}, names)
+	}
+}
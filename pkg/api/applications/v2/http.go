@@ -26,8 +26,10 @@ import (
 	"os"
 	"path"
 	"strings"
+	"sync"
 
 	"github.com/thestormforge/optimize-go/pkg/api"
+	experiments "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
 )
 
 func NewAPI(client api.Client) API {
@@ -43,6 +45,10 @@ func NewAPI(client api.Client) API {
 type httpAPI struct {
 	client   api.Client
 	endpoint string
+
+	// Experiment APIs bound to scenario experiment links
+	mu      sync.Mutex
+	expAPIs map[string]experiments.API
 }
 
 var _ API = &httpAPI{}
@@ -92,7 +98,7 @@ func (h *httpAPI) ListApplicationsByPage(ctx context.Context, u string) (Applica
 	switch resp.StatusCode {
 	case http.StatusOK:
 		api.UnmarshalMetadata(resp, &result.Metadata)
-		err = json.Unmarshal(body, &result)
+		err = api.UnmarshalResponse(h.client, resp, body, &result)
 		return result, err
 	default:
 		return result, api.NewUnexpectedError(resp, body)
@@ -180,7 +186,7 @@ func (h *httpAPI) GetApplication(ctx context.Context, u string) (Application, er
 	switch resp.StatusCode {
 	case http.StatusOK:
 		api.UnmarshalMetadata(resp, &result.Metadata)
-		err = json.Unmarshal(body, &result)
+		err = api.UnmarshalResponse(h.client, resp, body, &result)
 		return result, err
 	case http.StatusNotFound:
 		return result, api.NewError(ErrApplicationNotFound, resp, body)
@@ -234,6 +240,37 @@ func (h *httpAPI) UpdateApplicationByName(ctx context.Context, n ApplicationName
 	return h.UpdateApplication(ctx, u.String(), app)
 }
 
+func (h *httpAPI) PatchApplication(ctx context.Context, u string, patch ApplicationPatch) (Application, error) {
+	result := Application{}
+
+	req, err := httpNewJSONRequest(http.MethodPatch, u, patch)
+	if err != nil {
+		return result, err
+	}
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+
+	resp, body, err := h.client.Do(ctx, req)
+	if err != nil {
+		return result, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		api.UnmarshalMetadata(resp, &result.Metadata)
+		err = api.UnmarshalResponse(h.client, resp, body, &result)
+		return result, err
+	case http.StatusNoContent:
+		api.UnmarshalMetadata(resp, &result.Metadata)
+		return result, nil
+	case http.StatusNotFound:
+		return result, api.NewError(ErrApplicationNotFound, resp, body)
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return result, api.NewError(ErrApplicationInvalid, resp, body)
+	default:
+		return result, api.NewUnexpectedError(resp, body)
+	}
+}
+
 func (h *httpAPI) DeleteApplication(ctx context.Context, u string) error {
 	req, err := http.NewRequest(http.MethodDelete, u, nil)
 	if err != nil {
@@ -271,8 +308,11 @@ func (h *httpAPI) ListScenarios(ctx context.Context, u string, q ScenarioListQue
 
 	switch resp.StatusCode {
 	case http.StatusOK:
-		err = json.Unmarshal(body, &result)
+		api.UnmarshalMetadata(resp, &result.Metadata)
+		err = api.UnmarshalResponse(h.client, resp, body, &result)
 		return result, err
+	case http.StatusNotFound:
+		return result, api.NewError(ErrApplicationNotFound, resp, body)
 	default:
 		return result, api.NewUnexpectedError(resp, body)
 	}
@@ -333,7 +373,7 @@ func (h *httpAPI) CreateScenarioByName(ctx context.Context, u string, n Scenario
 	switch resp.StatusCode {
 	case http.StatusOK, http.StatusAccepted, http.StatusCreated:
 		api.UnmarshalMetadata(resp, &result.Metadata)
-		err = json.Unmarshal(body, &result)
+		err = api.UnmarshalResponse(h.client, resp, body, &result)
 		return result, err
 	case http.StatusBadRequest:
 		return result, api.NewError(ErrScenarioInvalid, resp, body)
@@ -362,7 +402,7 @@ func (h *httpAPI) GetScenario(ctx context.Context, u string) (Scenario, error) {
 	switch resp.StatusCode {
 	case http.StatusOK:
 		api.UnmarshalMetadata(resp, &result.Metadata)
-		err = json.Unmarshal(body, &result)
+		err = api.UnmarshalResponse(h.client, resp, body, &result)
 		return result, err
 	case http.StatusNotFound:
 		return result, api.NewError(ErrScenarioNotFound, resp, body)
@@ -396,7 +436,7 @@ func (h *httpAPI) UpdateScenario(ctx context.Context, u string, scn Scenario) (S
 	switch resp.StatusCode {
 	case http.StatusOK, http.StatusAccepted, http.StatusCreated:
 		api.UnmarshalMetadata(resp, &result.Metadata)
-		err = json.Unmarshal(body, &result)
+		err = api.UnmarshalResponse(h.client, resp, body, &result)
 		return result, err
 	case http.StatusBadRequest:
 		return result, api.NewError(ErrScenarioInvalid, resp, body)
@@ -430,6 +470,8 @@ func (h *httpAPI) DeleteScenario(ctx context.Context, u string) error {
 	switch resp.StatusCode {
 	case http.StatusOK, http.StatusNoContent:
 		return nil
+	case http.StatusNotFound:
+		return api.NewError(ErrScenarioNotFound, resp, body)
 	default:
 		return api.NewUnexpectedError(resp, body)
 	}
@@ -458,6 +500,51 @@ func (h *httpAPI) PatchScenario(ctx context.Context, u string, scn Scenario) err
 	}
 }
 
+func (h *httpAPI) GetScenarioExperiments(ctx context.Context, scn Scenario) (experiments.API, experiments.ExperimentList, error) {
+	u := scn.Link(api.RelationExperiments)
+	if u == "" {
+		return nil, experiments.ExperimentList{}, fmt.Errorf("malformed response, missing experiments link")
+	}
+
+	expAPI, err := h.ExperimentsAPI(u)
+	if err != nil {
+		return nil, experiments.ExperimentList{}, err
+	}
+
+	lst, err := expAPI.GetAllExperiments(ctx, experiments.ExperimentListQuery{})
+	return expAPI, lst, err
+}
+
+func (h *httpAPI) ExperimentsAPI(u string) (experiments.API, error) {
+	// Relative links are resolved against the server so they share a cache entry
+	eu, err := url.Parse(u)
+	if err != nil {
+		return nil, err
+	}
+	eu = h.client.URL(eu.String())
+	if eu.Scheme != "http" && eu.Scheme != "https" {
+		return nil, fmt.Errorf("invalid experiments link %q", u)
+	}
+	u = eu.String()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if expAPI, ok := h.expAPIs[u]; ok {
+		return expAPI, nil
+	}
+
+	expAPI, err := experiments.NewAPIWithEndpoint(h.client, u)
+	if err != nil {
+		return nil, err
+	}
+	if h.expAPIs == nil {
+		h.expAPIs = make(map[string]experiments.API)
+	}
+	h.expAPIs[u] = expAPI
+	return expAPI, nil
+}
+
 func (h *httpAPI) GetTemplate(ctx context.Context, u string) (Template, error) {
 	result := Template{}
 
@@ -473,8 +560,12 @@ func (h *httpAPI) GetTemplate(ctx context.Context, u string) (Template, error) {
 
 	switch resp.StatusCode {
 	case http.StatusOK:
-		err = json.Unmarshal(body, &result)
+		err = api.UnmarshalResponse(h.client, resp, body, &result)
 		return result, err
+	case http.StatusAccepted:
+		return result, api.NewError(ErrTemplateNotReady, resp, body)
+	case http.StatusNotFound:
+		return result, api.NewError(ErrTemplateNotFound, resp, body)
 	default:
 		return result, api.NewUnexpectedError(resp, body)
 	}
@@ -542,15 +633,44 @@ func (h *httpAPI) ListActivity(ctx context.Context, u string, q ActivityFeedQuer
 
 	switch resp.StatusCode {
 	case http.StatusOK:
-		err = json.Unmarshal(body, &result)
+		err = api.UnmarshalResponse(h.client, resp, body, &result)
 		result.SetBaseURL(u)
+		result.Items = q.Filter(result.Items) // Double check the dates in case the server ignored them
 		return result, err
 	default:
 		return result, api.NewUnexpectedError(resp, body)
 	}
 }
 
+func (h *httpAPI) GetActivity(ctx context.Context, u string) (ActivityItem, error) {
+	result := ActivityItem{}
+
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return result, err
+	}
+
+	resp, body, err := h.client.Do(ctx, req)
+	if err != nil {
+		return result, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		err = api.UnmarshalResponse(h.client, resp, body, &result)
+		return result, err
+	case http.StatusNotFound:
+		return result, api.NewError(ErrActivityNotFound, resp, body)
+	default:
+		return result, api.NewUnexpectedError(resp, body)
+	}
+}
+
 func (h *httpAPI) CreateActivity(ctx context.Context, u string, a Activity) error {
+	if err := a.Validate(); err != nil {
+		return err
+	}
+
 	req, err := httpNewJSONRequest(http.MethodPost, u, a)
 	if err != nil {
 		return err
@@ -628,7 +748,7 @@ func (h *httpAPI) SubscribeActivity(ctx context.Context, q ActivityFeedQuery) (S
 		return nil, err
 	}
 
-	return newSubscriber(h, feed), nil
+	return newSubscriber(h, api.StreamingClient(h.client), feed), nil
 }
 
 func (h *httpAPI) CreateRecommendation(ctx context.Context, u string) (api.Metadata, error) {
@@ -699,7 +819,7 @@ func (h *httpAPI) ListRecommendations(ctx context.Context, u string) (Recommenda
 	switch resp.StatusCode {
 	case http.StatusOK:
 		api.UnmarshalMetadata(resp, &result.Metadata)
-		err = json.Unmarshal(body, &result)
+		err = api.UnmarshalResponse(h.client, resp, body, &result)
 		return result, err
 	default:
 		return result, api.NewUnexpectedError(resp, body)
@@ -774,9 +894,13 @@ func (h *httpAPI) ListClusters(ctx context.Context, q ClusterListQuery) (Cluster
 	u := h.client.URL(h.endpoint + "../clusters")
 	u.RawQuery = url.Values(q.IndexQuery).Encode()
 
+	return h.ListClustersByPage(ctx, u.String())
+}
+
+func (h *httpAPI) ListClustersByPage(ctx context.Context, u string) (ClusterList, error) {
 	result := ClusterList{}
 
-	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	req, err := http.NewRequest(http.MethodGet, u, nil)
 	if err != nil {
 		return result, err
 	}
@@ -789,7 +913,7 @@ func (h *httpAPI) ListClusters(ctx context.Context, q ClusterListQuery) (Cluster
 	switch resp.StatusCode {
 	case http.StatusOK:
 		api.UnmarshalMetadata(resp, &result.Metadata)
-		err = json.Unmarshal(body, &result)
+		err = api.UnmarshalResponse(h.client, resp, body, &result)
 		return result, err
 	default:
 		return result, api.NewUnexpectedError(resp, body)
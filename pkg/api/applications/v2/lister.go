@@ -342,7 +342,7 @@ func (l *Lister) GetScenarioByNameOrTitle(ctx context.Context, app *Application,
 	return scnByTitle, nil
 }
 
-// ForEachCluster iterates over all the clusters.
+// ForEachCluster iterates over all the clusters matching the supplied query.
 func (l *Lister) ForEachCluster(ctx context.Context, q ClusterListQuery, f func(item *ClusterItem) error) error {
 	// Define a helper to iteratively (NOT recursively) visit clusters
 	forEach := func(lst ClusterList, err error) (string, error) {
@@ -351,6 +351,10 @@ func (l *Lister) ForEachCluster(ctx context.Context, q ClusterListQuery, f func(
 		}
 
 		for i := range lst.Items {
+			// Filter on the client in case the server does not support it
+			if !q.Matches(&lst.Items[i]) {
+				continue
+			}
 			if err := f(&lst.Items[i]); err != nil {
 				return "", err
 			}
@@ -362,11 +366,15 @@ func (l *Lister) ForEachCluster(ctx context.Context, q ClusterListQuery, f func(
 		return lst.Link(api.RelationNext), nil
 	}
 
+	// Overwrite the limit
+	if l.BatchSize > 0 {
+		q.SetLimit(l.BatchSize)
+	}
+
 	// Iterate over all clusters, starting with first page
 	u, err := forEach(l.API.ListClusters(ctx, q))
 	for u != "" && err == nil {
-		// At the time of writing, the clusters list API did not support paging
-		panic("not implemented")
+		u, err = forEach(l.API.ListClustersByPage(ctx, u))
 	}
 	return err
 }
@@ -0,0 +1,155 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thestormforge/optimize-go/pkg/api"
+)
+
+func TestHTTPAPI_GetScenarioExperiments(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2/applications/my-app/scenarios/testing/experiments/", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"experiments": [{"_metadata": {"Link": "</v2/applications/my-app/scenarios/testing/experiments/testing-abc>; rel=self"}}]}`)
+	}))
+	defer srv.Close()
+
+	client, err := api.NewClient(srv.URL, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	appAPI := NewAPI(client)
+
+	scn := Scenario{Metadata: api.Metadata{
+		"Link": []string{"<" + srv.URL + "/v2/applications/my-app/scenarios/testing/experiments/>; rel=https://stormforge.io/rel/experiments"},
+	}}
+	expAPI, lst, err := appAPI.GetScenarioExperiments(context.Background(), scn)
+	if assert.NoError(t, err) {
+		assert.NotNil(t, expAPI)
+		if assert.Len(t, lst.Experiments, 1) {
+			assert.Equal(t, "testing-abc", lst.Experiments[0].Name.String())
+		}
+	}
+
+	_, _, err = appAPI.GetScenarioExperiments(context.Background(), Scenario{})
+	assert.Error(t, err)
+}
+
+func TestHTTPAPI_ExperimentsAPI(t *testing.T) {
+	client, err := api.NewClient("https://api.example.com/", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	appAPI := NewAPI(client)
+
+	one, err := appAPI.ExperimentsAPI("https://api.example.com/v2/applications/my-app/scenarios/one/experiments/")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// The same link (including a relative form) returns the same instance
+	again, err := appAPI.ExperimentsAPI("https://api.example.com/v2/applications/my-app/scenarios/one/experiments/")
+	if assert.NoError(t, err) {
+		assert.Same(t, one, again)
+	}
+	relative, err := appAPI.ExperimentsAPI("/v2/applications/my-app/scenarios/one/experiments/")
+	if assert.NoError(t, err) {
+		assert.Same(t, one, relative)
+	}
+
+	two, err := appAPI.ExperimentsAPI("https://api.example.com/v2/applications/my-app/scenarios/two/experiments/")
+	if assert.NoError(t, err) {
+		assert.NotSame(t, one, two)
+	}
+
+	_, err = appAPI.ExperimentsAPI("https://api.example.com/%zz")
+	assert.Error(t, err)
+	_, err = appAPI.ExperimentsAPI("ftp://api.example.com/experiments/")
+	assert.Error(t, err)
+}
+
+func TestHTTPAPI_ListScenarios_Pagination(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("offset") {
+		case "":
+			w.Header().Set("Link", "</v2/applications/my-app/scenarios/?offset=2&limit=2>; rel=next")
+			_, _ = w.Write([]byte(`{"totalCount": 3, "scenarios": [{"name": "one"}, {"name": "two"}]}`))
+		case "2":
+			w.Header().Set("Link", "</v2/applications/my-app/scenarios/?offset=0&limit=2>; rel=prev")
+			_, _ = w.Write([]byte(`{"totalCount": 3, "scenarios": [{"name": "three"}]}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	client, err := api.NewClient(srv.URL, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	appAPI := NewAPI(client)
+
+	q := ScenarioListQuery{}
+	q.SetLimit(2)
+	lst, err := appAPI.ListScenarios(context.Background(), client.URL("/v2/applications/my-app/scenarios/").String(), q)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 3, lst.TotalCount)
+		assert.Len(t, lst.Scenarios, 2)
+		assert.Equal(t, srv.URL+"/v2/applications/my-app/scenarios/?offset=2&limit=2", lst.Link(api.RelationNext))
+	}
+
+	app := Application{Metadata: api.Metadata{
+		"Link": []string{"<" + srv.URL + "/v2/applications/my-app/scenarios/>; rel=https://stormforge.io/rel/scenarios"},
+	}}
+	var names []string
+	err = (&Lister{API: appAPI, BatchSize: 2}).ForEachScenario(context.Background(), &app, ScenarioListQuery{}, func(item *ScenarioItem) error {
+		names = append(names, item.Name.String())
+		return nil
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"one", "two", "three"}, names)
+	}
+}
+
+func TestHTTPAPI_DeleteScenario_NotFound(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodDelete, r.Method)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":"scenario not found"}`))
+	}))
+	defer srv.Close()
+
+	client, err := api.NewClient(srv.URL, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	err = NewAPI(client).DeleteScenario(context.Background(), client.URL("/v2/applications/my-app/scenarios/missing").String())
+	var apiErr *api.Error
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, ErrScenarioNotFound, apiErr.Type)
+	}
+}
@@ -0,0 +1,257 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/thestormforge/optimize-go/pkg/api/internal/backoff"
+)
+
+// SSESubscriber receives activity pushed from the server as server-sent events.
+type SSESubscriber struct {
+	// The HTTP client used to connect to the feed. Defaults to `http.DefaultClient`,
+	// the client must not impose an overall timeout on requests.
+	HTTPClient *http.Client
+	// The URL of the event stream.
+	FeedURL string
+	// Base time to wait before reconnecting after the stream is dropped, the delay grows
+	// with each failed attempt (up to one minute). Defaults to 3 seconds, the server may
+	// override this value using the "retry" field.
+	ReconnectDelay time.Duration
+	// The subscriber to use if the server does not support server-sent events.
+	Fallback Subscriber
+	// An optional handler for errors which do not end the subscription, for example
+	// failed reconnect attempts or malformed events.
+	ErrorHandler func(error)
+	// Flag indicating that failed activities should still be reported.
+	ReportFailedActivities bool
+
+	// The identifier of the last event received, used to resume the stream.
+	lastEventID string
+	// Backoff used between reconnect attempts.
+	reconnect backoff.Backoff
+}
+
+// Subscribe streams activity, blocking until the supplied context is finished, the
+// initial connection to the activity endpoint fails or the server rejects a reconnect
+// attempt. Dropped streams are resumed from the last event received.
+func (s *SSESubscriber) Subscribe(ctx context.Context, ch chan<- ActivityItem) error {
+	resp, err := s.connect(ctx)
+	if err != nil {
+		if _, ok := err.(*sseUnsupportedError); ok && s.Fallback != nil {
+			return s.Fallback.Subscribe(ctx, ch)
+		}
+		close(ch)
+		return err
+	}
+
+	// Close the channel when we are done sending things
+	defer close(ch)
+
+	for {
+		err := s.read(ctx, resp.Body, ch)
+		_ = resp.Body.Close()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			s.handleError(err)
+		}
+
+		// The stream was dropped, reconnect
+		if resp, err = s.reconnectStream(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// reconnectStream attempts to re-open the event stream until it succeeds, the context
+// is done or the server rejects the request.
+func (s *SSESubscriber) reconnectStream(ctx context.Context) (*http.Response, error) {
+	s.reconnect.Base = s.reconnectDelay()
+	s.reconnect.Reset()
+
+	for {
+		t := time.NewTimer(s.reconnect.Next())
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, ctx.Err()
+		case <-t.C:
+		}
+
+		resp, err := s.connect(ctx)
+		if err == nil {
+			return resp, nil
+		}
+
+		var unsupported *sseUnsupportedError
+		if ctx.Err() != nil || errors.As(err, &unsupported) && unsupported.permanent() {
+			return nil, err
+		}
+		s.handleError(err)
+	}
+}
+
+// sseUnsupportedError indicates the server did not respond with an event stream.
+type sseUnsupportedError struct {
+	status      int
+	contentType string
+}
+
+func (e *sseUnsupportedError) Error() string {
+	return fmt.Sprintf("server-sent events are not supported (%s, %q)", http.StatusText(e.status), e.contentType)
+}
+
+// permanent returns true if retrying the request is not expected to succeed.
+func (e *sseUnsupportedError) permanent() bool {
+	switch {
+	case e.status == http.StatusRequestTimeout, e.status == http.StatusTooManyRequests:
+		return false
+	case e.status == http.StatusOK, e.status >= 400 && e.status < 500:
+		return true
+	default:
+		return false
+	}
+}
+
+// connect opens the event stream, resuming from the last event received.
+func (s *SSESubscriber) connect(ctx context.Context) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.FeedURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+	if s.lastEventID != "" {
+		req.Header.Set("Last-Event-ID", s.lastEventID)
+	}
+
+	client := s.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if resp.StatusCode != http.StatusOK || mediaType != "text/event-stream" {
+		_ = resp.Body.Close()
+		return nil, &sseUnsupportedError{status: resp.StatusCode, contentType: mediaType}
+	}
+	return resp, nil
+}
+
+// read dispatches events from the stream until it ends.
+func (s *SSESubscriber) read(ctx context.Context, r io.Reader, ch chan<- ActivityItem) error {
+	var id string
+	var data []string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// A blank line dispatches the event
+		if line == "" {
+			if err := s.dispatch(ctx, id, data, ch); err != nil {
+				return err
+			}
+			data = data[:0]
+			continue
+		}
+
+		// Lines starting with a colon are comments
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value := line, ""
+		if i := strings.IndexByte(line, ':'); i >= 0 {
+			field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+		}
+
+		switch field {
+		case "id":
+			id = value
+		case "data":
+			data = append(data, value)
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms >= 0 {
+				s.ReconnectDelay = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return io.EOF
+}
+
+// dispatch sends a single event to the channel.
+func (s *SSESubscriber) dispatch(ctx context.Context, id string, data []string, ch chan<- ActivityItem) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	// Malformed events are skipped so they are not replayed when the stream is resumed
+	s.lastEventID = id
+	ai := ActivityItem{}
+	if err := json.Unmarshal([]byte(strings.Join(data, "\n")), &ai); err != nil {
+		s.handleError(fmt.Errorf("malformed event %q: %w", id, err))
+		return nil
+	}
+
+	// Optionally skip items that have a failure reason associated with them
+	if !s.ReportFailedActivities && ai.StormForge != nil && ai.StormForge.FailureReason != "" {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case ch <- ai:
+		return nil
+	}
+}
+
+func (s *SSESubscriber) handleError(err error) {
+	if s.ErrorHandler != nil {
+		s.ErrorHandler(err)
+	}
+}
+
+func (s *SSESubscriber) reconnectDelay() time.Duration {
+	if s.ReconnectDelay > 0 {
+		return s.ReconnectDelay
+	}
+	return 3 * time.Second
+}
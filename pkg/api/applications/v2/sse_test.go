@@ -0,0 +1,254 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thestormforge/optimize-go/pkg/api"
+	"golang.org/x/oauth2"
+)
+
+func TestSSESubscriber_Subscribe(t *testing.T) {
+	var lastEventIDs []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "text/event-stream", r.Header.Get("Accept"))
+		lastEventIDs = append(lastEventIDs, r.Header.Get("Last-Event-ID"))
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		switch len(lastEventIDs) {
+		case 1:
+			// Send a single (multi-line) event and drop the connection
+			_, _ = fmt.Fprint(w, ": welcome\nretry: 1\n\nid: 1\ndata: {\"id\": \"1\",\ndata: \"tags\": [\"scan\"]}\n\n")
+		default:
+			_, _ = fmt.Fprint(w, "id: 2\ndata: {\"id\": \"2\", \"tags\": [\"run\"]}\n\n")
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		}
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	s := &SSESubscriber{HTTPClient: srv.Client(), FeedURL: srv.URL}
+	ch := make(chan ActivityItem)
+	done := make(chan error)
+	go func() { done <- s.Subscribe(ctx, ch) }()
+
+	var kinds []ActivityKind
+	for ai := range ch {
+		kinds = append(kinds, ai.Kind())
+		if len(kinds) == 2 {
+			cancel()
+		}
+	}
+
+	assert.ErrorIs(t, <-done, context.Canceled)
+	assert.Equal(t, []ActivityKind{ActivityKindScan, ActivityKindRun}, kinds)
+	assert.Equal(t, []string{"", "1"}, lastEventIDs)
+}
+
+// fakeSubscriber sends a fixed list of items.
+type fakeSubscriber []ActivityItem
+
+func (s fakeSubscriber) Subscribe(ctx context.Context, ch chan<- ActivityItem) error {
+	defer close(ch)
+	for _, ai := range s {
+		ch <- ai
+	}
+	return nil
+}
+
+func TestSSESubscriber_Fallback(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/feed+json")
+		_, _ = fmt.Fprint(w, `{"items": []}`)
+	}))
+	defer srv.Close()
+
+	s := &SSESubscriber{
+		HTTPClient: srv.Client(),
+		FeedURL:    srv.URL,
+		Fallback:   fakeSubscriber{{ID: "1"}},
+	}
+	ch := make(chan ActivityItem)
+	done := make(chan error)
+	go func() { done <- s.Subscribe(context.Background(), ch) }()
+
+	var ids []string
+	for ai := range ch {
+		ids = append(ids, ai.ID)
+	}
+	assert.NoError(t, <-done)
+	assert.Equal(t, []string{"1"}, ids)
+
+	// Without a fallback, the subscription fails
+	s.Fallback = nil
+	ch = make(chan ActivityItem)
+	assert.Error(t, s.Subscribe(context.Background(), ch))
+}
+
+func TestSSESubscriber_Subscribe_Recover(t *testing.T) {
+	var lastEventIDs []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastEventIDs = append(lastEventIDs, r.Header.Get("Last-Event-ID"))
+		switch len(lastEventIDs) {
+		case 1:
+			// Send a malformed event followed by a good event and drop the connection
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = fmt.Fprint(w, "id: 1\ndata: {\"id\": \n\nid: 2\ndata: {\"id\": \"2\", \"tags\": [\"scan\"]}\n\n")
+		case 2:
+			// Fail the first reconnect attempt
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = fmt.Fprint(w, "id: 3\ndata: {\"id\": \"3\", \"tags\": [\"run\"]}\n\n")
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		}
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var mu sync.Mutex
+	var errs []error
+	s := &SSESubscriber{
+		HTTPClient:     srv.Client(),
+		FeedURL:        srv.URL,
+		ReconnectDelay: time.Millisecond,
+		ErrorHandler: func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			errs = append(errs, err)
+		},
+	}
+	ch := make(chan ActivityItem)
+	done := make(chan error)
+	go func() { done <- s.Subscribe(ctx, ch) }()
+
+	var ids []string
+	for ai := range ch {
+		ids = append(ids, ai.ID)
+		if len(ids) == 2 {
+			cancel()
+		}
+	}
+
+	assert.ErrorIs(t, <-done, context.Canceled)
+	assert.Equal(t, []string{"2", "3"}, ids)
+	assert.Equal(t, []string{"", "2", "2"}, lastEventIDs)
+
+	// Both the malformed event and the failed reconnect are reported
+	mu.Lock()
+	defer mu.Unlock()
+	if assert.Len(t, errs, 2) {
+		assert.Contains(t, errs[0].Error(), "malformed event")
+	}
+}
+
+func TestSSESubscriber_Subscribe_Rejected(t *testing.T) {
+	var connections int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		connections++
+		if connections > 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = fmt.Fprint(w, "id: 1\ndata: {\"id\": \"1\"}\n\n")
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	s := &SSESubscriber{HTTPClient: srv.Client(), FeedURL: srv.URL, ReconnectDelay: time.Millisecond}
+	ch := make(chan ActivityItem)
+	done := make(chan error)
+	go func() { done <- s.Subscribe(ctx, ch) }()
+
+	var ids []string
+	for ai := range ch {
+		ids = append(ids, ai.ID)
+	}
+
+	var unsupported *sseUnsupportedError
+	if err := <-done; assert.ErrorAs(t, err, &unsupported) {
+		assert.Equal(t, http.StatusUnauthorized, unsupported.status)
+	}
+	assert.Equal(t, []string{"1"}, ids)
+	assert.Equal(t, 2, connections)
+}
+
+func TestHTTPAPI_SubscribeActivity_SSE(t *testing.T) {
+	var srv *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/applications/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Link", "</v2/activity/>; rel=alternate")
+	})
+	mux.HandleFunc("/v2/activity/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/feed+json")
+		_, _ = fmt.Fprintf(w, `{"feed_url": "%s/v2/activity/", "hubs": [{"type": "sse", "url": "%s/v2/activity/stream"}], "items": []}`, srv.URL, srv.URL)
+	})
+	mux.HandleFunc("/v2/activity/stream", func(w http.ResponseWriter, r *http.Request) {
+		// The stream must be authorized using the configured client
+		assert.Equal(t, "Bearer test", r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = fmt.Fprint(w, "id: 1\ndata: {\"id\": \"1\"}\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+	srv = httptest.NewServer(mux)
+	defer srv.Close()
+
+	client, err := api.NewClient(srv.URL, nil, api.WithTokenSource(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "test"})))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sub, err := NewAPI(client).SubscribeActivity(ctx, ActivityFeedQuery{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	if assert.IsType(t, &SSESubscriber{}, sub) {
+		assert.NotSame(t, http.DefaultClient, sub.(*SSESubscriber).HTTPClient)
+	}
+
+	ch := make(chan ActivityItem)
+	done := make(chan error)
+	go func() { done <- sub.Subscribe(ctx, ch) }()
+
+	ai := <-ch
+	assert.Equal(t, "1", ai.ID)
+	cancel()
+	for range ch {
+	}
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
@@ -20,21 +20,31 @@ import (
 	"context"
 	"errors"
 	"math/rand"
+	"net/http"
 	"os"
 	"sort"
 	"time"
 
 	"github.com/thestormforge/optimize-go/pkg/api"
+	"github.com/thestormforge/optimize-go/pkg/api/internal/backoff"
 )
 
-// newSubscriber returns a subscriber for the supplied feed.
-func newSubscriber(api API, feed ActivityFeed) Subscriber {
+// newSubscriber returns a subscriber for the supplied feed. The HTTP client is used for
+// streaming subscriptions and must not impose an overall timeout on requests.
+func newSubscriber(api API, client *http.Client, feed ActivityFeed) Subscriber {
 	// Check the feed hubs for any subscription strategies we support
 	for _, hub := range feed.Hubs {
 		switch hub.Type {
 		case "poll":
 			// Allow the server to force polling
 			return &PollingSubscriber{API: api, FeedURL: hub.URL}
+		case "sse":
+			// Prefer server push, falling back to polling the feed
+			return &SSESubscriber{
+				HTTPClient: client,
+				FeedURL:    hub.URL,
+				Fallback:   &PollingSubscriber{API: api, FeedURL: feed.FeedURL},
+			}
 		}
 	}
 
@@ -42,6 +52,33 @@ func newSubscriber(api API, feed ActivityFeed) Subscriber {
 	return &PollingSubscriber{API: api, FeedURL: feed.FeedURL}
 }
 
+// WaitForActivity polls the activity identified by the supplied URL until it reaches
+// a terminal state or the context is done. The final state of the activity is returned.
+func WaitForActivity(ctx context.Context, appAPI API, u string, pollInterval time.Duration) (ActivityItem, error) {
+	if pollInterval <= 0 {
+		pollInterval = 5 * time.Second
+	}
+
+	t := time.NewTicker(pollInterval)
+	defer t.Stop()
+
+	for {
+		ai, err := appAPI.GetActivity(ctx, u)
+		if err != nil {
+			return ai, err
+		}
+		if ai.Done() {
+			return ai, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ai, ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
 // PollingSubscriber is a primitive strategy that simply polls for changes.
 type PollingSubscriber struct {
 	// The API instance used to fetch the feed.
@@ -58,6 +95,8 @@ type PollingSubscriber struct {
 
 	// The server may periodically request a longer delay.
 	rateLimit time.Duration
+	// Backoff used when the server rate limits without specifying a delay.
+	rateLimitBackoff backoff.Backoff
 	// The last feed item identifier acknowledged by this subscriber.
 	lastID string
 }
@@ -112,6 +151,9 @@ func (s *PollingSubscriber) Subscribe(ctx context.Context, ch chan<- ActivityIte
 				switch apiErr.Type {
 				case ErrActivityRateLimited:
 					s.rateLimit = apiErr.RetryAfter
+					if s.rateLimit <= 0 {
+						s.rateLimit = s.rateLimitBackoff.Next()
+					}
 					continue
 				}
 			}
@@ -119,28 +161,40 @@ func (s *PollingSubscriber) Subscribe(ctx context.Context, ch chan<- ActivityIte
 			return err
 		}
 
+		s.rateLimitBackoff.Reset()
 		s.notify(f.Items, ch)
 	}
 }
 
-// notify sends all the items from the supplied feed to the channel.
+// notify sends all the items from the supplied feed to the channel in chronological order.
 // IMPORTANT: this function assumes item identifiers can be compared lexicographically.
 func (s *PollingSubscriber) notify(items []ActivityItem, ch chan<- ActivityItem) {
 	// Make sure the items are sorted by their identifier
 	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+
+	// Filter out items that we have already seen
+	var newItems []ActivityItem
 	for i := range items {
-		// Ignore items that we have already seen
 		if s.lastID != "" && items[i].ID <= s.lastID {
 			continue
 		}
+		newItems = append(newItems, items[i])
+	}
+
+	// Deliver the new items in the order they occurred, falling back to the identifier order
+	sort.SliceStable(newItems, func(i, j int) bool { return newItems[i].Timestamp().Before(newItems[j].Timestamp()) })
+	for i := range newItems {
+		// The last ID is the largest identifier seen, regardless of whether it is delivered
+		if newItems[i].ID > s.lastID {
+			s.lastID = newItems[i].ID
+		}
 
 		// Optionally skip items that have a failure reason associated with them
-		if !s.ReportFailedActivities && items[i].StormForge != nil && items[i].StormForge.FailureReason != "" {
+		if !s.ReportFailedActivities && newItems[i].StormForge != nil && newItems[i].StormForge.FailureReason != "" {
 			continue
 		}
 
-		// Send the item to the channel and update the last ID
-		ch <- items[i]
-		s.lastID = items[i].ID
+		// Send the item to the channel
+		ch <- newItems[i]
 	}
 }
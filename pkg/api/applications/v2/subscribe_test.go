@@ -0,0 +1,125 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thestormforge/optimize-go/pkg/api"
+)
+
+func TestPollingSubscriber_notify(t *testing.T) {
+	now := time.Date(2022, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		desc     string
+		lastID   string
+		items    []ActivityItem
+		expected []string
+		lastSeen string
+	}{
+		{
+			desc: "chronological",
+			items: []ActivityItem{
+				{ID: "3", DatePublished: now.Add(1 * time.Minute)},
+				{ID: "1", DatePublished: now.Add(2 * time.Minute)},
+				{ID: "2", DatePublished: now},
+			},
+			expected: []string{"2", "3", "1"},
+			lastSeen: "3",
+		},
+		{
+			desc: "no timestamps",
+			items: []ActivityItem{
+				{ID: "2"},
+				{ID: "1"},
+			},
+			expected: []string{"1", "2"},
+			lastSeen: "2",
+		},
+		{
+			desc:   "already seen",
+			lastID: "2",
+			items: []ActivityItem{
+				{ID: "4", DateModified: now},
+				{ID: "2", DatePublished: now.Add(-time.Hour)},
+				{ID: "3", DatePublished: now.Add(time.Minute)},
+			},
+			expected: []string{"4", "3"},
+			lastSeen: "4",
+		},
+		{
+			desc: "failed",
+			items: []ActivityItem{
+				{ID: "2", DatePublished: now, StormForge: &ActivityExtension{ActivityFailure: ActivityFailure{FailureReason: "oops"}}},
+				{ID: "1", DatePublished: now.Add(time.Minute)},
+			},
+			expected: []string{"1"},
+			lastSeen: "2",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			s := &PollingSubscriber{lastID: c.lastID}
+			ch := make(chan ActivityItem, len(c.items))
+			s.notify(c.items, ch)
+			close(ch)
+
+			var actual []string
+			for ai := range ch {
+				actual = append(actual, ai.ID)
+			}
+			assert.Equal(t, c.expected, actual)
+			assert.Equal(t, c.lastSeen, s.lastID)
+		})
+	}
+}
+
+func TestWaitForActivity(t *testing.T) {
+	var polls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		status := ActivityStatusPending
+		if polls >= 3 {
+			status = ActivityStatusCompleted
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"id": "1", "tags": ["run"], "_stormforge": {"status": %q}}`, status)
+	}))
+	defer srv.Close()
+
+	client, err := api.NewClient(srv.URL, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ai, err := WaitForActivity(ctx, NewAPI(client), srv.URL+"/activity/1", time.Millisecond)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 3, polls)
+		assert.Equal(t, ActivityStatusCompleted, ai.StormForge.Status)
+		assert.Equal(t, ActivityKindRun, ai.Kind())
+	}
+}
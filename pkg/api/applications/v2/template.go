@@ -0,0 +1,87 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/thestormforge/optimize-go/pkg/api"
+	experiments "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
+)
+
+// WaitForTemplate polls the template identified by the supplied URL until it is available
+// or the context is done. Templates are produced asynchronously by a scan, until the scan
+// completes the server may report the template as missing or not ready.
+func WaitForTemplate(ctx context.Context, appAPI API, u string, pollInterval time.Duration) (Template, error) {
+	if pollInterval <= 0 {
+		pollInterval = 5 * time.Second
+	}
+
+	t := time.NewTicker(pollInterval)
+	defer t.Stop()
+
+	for {
+		tmpl, err := appAPI.GetTemplate(ctx, u)
+		var apiErr *api.Error
+		switch {
+		case err == nil:
+			return tmpl, nil
+		case errors.As(err, &apiErr) && (apiErr.Type == ErrTemplateNotFound || apiErr.Type == ErrTemplateNotReady):
+			// Keep waiting
+		default:
+			return tmpl, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return tmpl, ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// ExperimentParameters returns the template parameters as experiment parameters.
+func (t *Template) ExperimentParameters() []experiments.Parameter {
+	var params []experiments.Parameter
+	for _, tp := range t.Parameters {
+		p := experiments.Parameter{
+			Name:   tp.Name,
+			Type:   experiments.ParameterType(tp.Type),
+			Values: tp.Values,
+		}
+		if tp.Bounds != nil {
+			p.Bounds = &experiments.Bounds{Min: tp.Bounds.Min, Max: tp.Bounds.Max}
+		}
+		params = append(params, p)
+	}
+	return params
+}
+
+// ExperimentMetrics returns the template metrics as experiment metrics.
+func (t *Template) ExperimentMetrics() []experiments.Metric {
+	var metrics []experiments.Metric
+	for _, tm := range t.Metrics {
+		metrics = append(metrics, experiments.Metric{
+			Name:     tm.Name,
+			Minimize: tm.Minimize,
+			Optimize: tm.Optimize,
+		})
+	}
+	return metrics
+}
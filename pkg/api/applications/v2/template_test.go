@@ -0,0 +1,105 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thestormforge/optimize-go/pkg/api"
+	experiments "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
+)
+
+func TestTemplate_Experiment(t *testing.T) {
+	optimize := false
+	template := Template{
+		Parameters: []TemplateParameter{
+			{Name: "cpu", Type: "int", Bounds: &TemplateParameterBounds{Min: "100", Max: "4000"}},
+			{Name: "gc", Type: "categorical", Values: []string{"G1", "Parallel"}},
+		},
+		Metrics: []TemplateMetric{
+			{Name: "cost", Minimize: true},
+			{Name: "duration", Optimize: &optimize},
+		},
+	}
+
+	assert.Equal(t, []experiments.Parameter{
+		{Name: "cpu", Type: experiments.ParameterTypeInteger, Bounds: &experiments.Bounds{Min: "100", Max: "4000"}},
+		{Name: "gc", Type: experiments.ParameterTypeCategorical, Values: []string{"G1", "Parallel"}},
+	}, template.ExperimentParameters())
+	assert.Equal(t, []experiments.Metric{
+		{Name: "cost", Minimize: true},
+		{Name: "duration", Optimize: &optimize},
+	}, template.ExperimentMetrics())
+}
+
+func TestWaitForTemplate(t *testing.T) {
+	var polls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		switch polls {
+		case 1:
+			w.WriteHeader(http.StatusNotFound)
+		case 2:
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"parameters": [{"name": "cpu", "type": "int"}], "metrics": [{"name": "cost", "minimize": true}]}`))
+		}
+	}))
+	defer srv.Close()
+
+	client, err := api.NewClient(srv.URL, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tmpl, err := WaitForTemplate(ctx, NewAPI(client), srv.URL+"/template", time.Millisecond)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 3, polls)
+		assert.Len(t, tmpl.Parameters, 1)
+		assert.Len(t, tmpl.Metrics, 1)
+	}
+}
+
+func TestWaitForTemplate_Error(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	client, err := api.NewClient(srv.URL, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err = WaitForTemplate(ctx, NewAPI(client), srv.URL+"/template", time.Millisecond)
+	var apiErr *api.Error
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, api.ErrUnexpected, apiErr.Type)
+	}
+}
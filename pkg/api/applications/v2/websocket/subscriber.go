@@ -0,0 +1,246 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package websocket provides an optional WebSocket transport for application activity
+// feeds. It is kept separate from the applications API so that only consumers which use
+// WebSockets depend on "golang.org/x/net".
+package websocket
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	applications "github.com/thestormforge/optimize-go/pkg/api/applications/v2"
+	"github.com/thestormforge/optimize-go/pkg/api/internal/backoff"
+	"golang.org/x/net/websocket"
+	"golang.org/x/oauth2"
+)
+
+// Subscriber receives activity frames from a WebSocket feed. Unlike the other
+// subscribers, it is never selected automatically and must be created explicitly.
+type Subscriber struct {
+	// The WebSocket URL of the feed (i.e. using the "ws" or "wss" scheme).
+	FeedURL string
+	// The origin sent with the WebSocket handshake. Defaults to the feed URL.
+	Origin string
+	// An optional source of tokens used to authorize the handshake.
+	TokenSource oauth2.TokenSource
+	// Base time to wait before reconnecting after the connection is dropped, the delay
+	// grows with each failed attempt (up to one minute). Defaults to 3 seconds.
+	ReconnectDelay time.Duration
+	// An optional handler for errors which do not end the subscription, for example
+	// failed reconnect attempts or malformed frames.
+	ErrorHandler func(error)
+	// Flag indicating that failed activities should still be reported.
+	ReportFailedActivities bool
+
+	// Backoff used between reconnect attempts.
+	reconnect backoff.Backoff
+}
+
+// NewSubscriber returns a subscriber for the supplied WebSocket feed URL.
+func NewSubscriber(feedURL string) *Subscriber {
+	return &Subscriber{FeedURL: feedURL}
+}
+
+// Subscribe reads activity, blocking until the supplied context is finished, the
+// initial connection to the feed fails or the server rejects a reconnect attempt.
+// Dropped connections are re-established.
+func (s *Subscriber) Subscribe(ctx context.Context, ch chan<- applications.ActivityItem) error {
+	// Close the channel when we are done sending things
+	defer close(ch)
+
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return err
+	}
+
+	for {
+		s.read(ctx, conn, ch)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		// The connection was dropped, reconnect
+		if conn, err = s.redial(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// redial attempts to re-establish the connection until it succeeds, the context is
+// done or the server rejects the handshake.
+func (s *Subscriber) redial(ctx context.Context) (*websocket.Conn, error) {
+	s.reconnect.Base = s.reconnectDelay()
+	s.reconnect.Reset()
+
+	for {
+		t := time.NewTimer(s.reconnect.Next())
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, ctx.Err()
+		case <-t.C:
+		}
+
+		conn, err := s.dial(ctx)
+		if err == nil {
+			return conn, nil
+		}
+
+		// A rejected handshake (e.g. unauthorized) will not succeed by retrying
+		var dialErr *websocket.DialError
+		if ctx.Err() != nil || errors.As(err, &dialErr) && dialErr.Err == websocket.ErrBadStatus {
+			return nil, err
+		}
+		s.handleError(err)
+	}
+}
+
+// dial establishes a new connection to the feed.
+func (s *Subscriber) dial(ctx context.Context) (*websocket.Conn, error) {
+	origin := s.Origin
+	if origin == "" {
+		u, err := url.Parse(s.FeedURL)
+		if err != nil {
+			return nil, err
+		}
+		u.Scheme = map[string]string{"ws": "http", "wss": "https"}[u.Scheme]
+		origin = u.String()
+	}
+
+	cfg, err := websocket.NewConfig(s.FeedURL, origin)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.TokenSource != nil {
+		tok, err := s.TokenSource.Token()
+		if err != nil {
+			return nil, err
+		}
+		cfg.Header = http.Header{}
+		cfg.Header.Set("Authorization", tok.Type()+" "+tok.AccessToken)
+	}
+
+	return dialContext(ctx, cfg)
+}
+
+// dialContext is like `websocket.DialConfig` except the context can end the dial or handshake.
+func dialContext(ctx context.Context, cfg *websocket.Config) (*websocket.Conn, error) {
+	addr := cfg.Location.Host
+	if cfg.Location.Port() == "" {
+		addr = net.JoinHostPort(cfg.Location.Hostname(), map[string]string{"ws": "80", "wss": "443"}[cfg.Location.Scheme])
+	}
+
+	d := &net.Dialer{}
+	if cfg.Dialer != nil {
+		d = cfg.Dialer
+	}
+
+	var nc net.Conn
+	var err error
+	switch cfg.Location.Scheme {
+	case "ws":
+		nc, err = d.DialContext(ctx, "tcp", addr)
+	case "wss":
+		nc, err = (&tls.Dialer{NetDialer: d, Config: cfg.TlsConfig}).DialContext(ctx, "tcp", addr)
+	default:
+		err = websocket.ErrBadScheme
+	}
+	if err != nil {
+		return nil, &websocket.DialError{Config: cfg, Err: err}
+	}
+
+	// Unblock the handshake if the context ends first
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = nc.Close()
+		case <-done:
+		}
+	}()
+
+	conn, err := websocket.NewClient(cfg, nc)
+	close(done)
+	if err != nil {
+		_ = nc.Close()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, &websocket.DialError{Config: cfg, Err: err}
+	}
+	return conn, nil
+}
+
+// read sends activity frames to the channel until the connection or context ends.
+func (s *Subscriber) read(ctx context.Context, conn *websocket.Conn, ch chan<- applications.ActivityItem) {
+	// Unblock the receive if the context ends first
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+		}
+		_ = conn.Close()
+	}()
+
+	for {
+		var data []byte
+		if err := websocket.Message.Receive(conn, &data); err != nil {
+			return
+		}
+
+		// Malformed frames are skipped, they do not invalidate the connection
+		ai := applications.ActivityItem{}
+		if err := json.Unmarshal(data, &ai); err != nil {
+			s.handleError(err)
+			continue
+		}
+
+		// Optionally skip items that have a failure reason associated with them
+		if !s.ReportFailedActivities && ai.StormForge != nil && ai.StormForge.FailureReason != "" {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case ch <- ai:
+		}
+	}
+}
+
+func (s *Subscriber) handleError(err error) {
+	if s.ErrorHandler != nil {
+		s.ErrorHandler(err)
+	}
+}
+
+func (s *Subscriber) reconnectDelay() time.Duration {
+	if s.ReconnectDelay > 0 {
+		return s.ReconnectDelay
+	}
+	return 3 * time.Second
+}
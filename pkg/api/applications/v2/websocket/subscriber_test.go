@@ -0,0 +1,162 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package websocket
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	applications "github.com/thestormforge/optimize-go/pkg/api/applications/v2"
+	"golang.org/x/net/websocket"
+	"golang.org/x/oauth2"
+)
+
+func TestSubscriber_Subscribe(t *testing.T) {
+	var connections int32
+	handler := websocket.Handler(func(conn *websocket.Conn) {
+		assert.Equal(t, "Bearer test", conn.Request().Header.Get("Authorization"))
+		switch atomic.LoadInt32(&connections) {
+		case 1:
+			// Send a malformed frame and an item, then drop the connection
+			assert.NoError(t, websocket.Message.Send(conn, `{"id": `))
+			assert.NoError(t, websocket.JSON.Send(conn, applications.ActivityItem{ID: "1", Tags: []string{applications.TagScan}}))
+		default:
+			assert.NoError(t, websocket.JSON.Send(conn, applications.ActivityItem{ID: "2", Tags: []string{applications.TagRun}}))
+			var ignored string
+			_ = websocket.Message.Receive(conn, &ignored)
+		}
+	})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Fail the first reconnect attempt before the handshake completes
+		if atomic.AddInt32(&connections, 1) == 2 {
+			if hj, ok := w.(http.Hijacker); ok {
+				if conn, _, err := hj.Hijack(); err == nil {
+					_ = conn.Close()
+				}
+			}
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var mu sync.Mutex
+	var errs []error
+	s := NewSubscriber("ws" + strings.TrimPrefix(srv.URL, "http"))
+	s.TokenSource = oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "test"})
+	s.ReconnectDelay = time.Millisecond
+	s.ErrorHandler = func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		errs = append(errs, err)
+	}
+
+	ch := make(chan applications.ActivityItem)
+	done := make(chan error)
+	go func() { done <- s.Subscribe(ctx, ch) }()
+
+	var ids []string
+	for ai := range ch {
+		ids = append(ids, ai.ID)
+		if len(ids) == 2 {
+			cancel()
+		}
+	}
+
+	assert.ErrorIs(t, <-done, context.Canceled)
+	assert.Equal(t, []string{"1", "2"}, ids)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&connections))
+
+	// Both the malformed frame and the failed reconnect are reported
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, errs, 2)
+}
+
+func TestSubscriber_Subscribe_Rejected(t *testing.T) {
+	var connections int32
+	handler := websocket.Handler(func(conn *websocket.Conn) {
+		assert.NoError(t, websocket.JSON.Send(conn, applications.ActivityItem{ID: "1", Tags: []string{applications.TagScan}}))
+	})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Reject reconnect attempts
+		if atomic.AddInt32(&connections, 1) > 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	s := NewSubscriber("ws" + strings.TrimPrefix(srv.URL, "http"))
+	s.ReconnectDelay = time.Millisecond
+
+	ch := make(chan applications.ActivityItem)
+	done := make(chan error)
+	go func() { done <- s.Subscribe(ctx, ch) }()
+
+	var ids []string
+	for ai := range ch {
+		ids = append(ids, ai.ID)
+	}
+
+	var dialErr *websocket.DialError
+	if err := <-done; assert.ErrorAs(t, err, &dialErr) {
+		assert.Equal(t, websocket.ErrBadStatus, dialErr.Err)
+	}
+	assert.Equal(t, []string{"1"}, ids)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&connections))
+}
+
+func TestSubscriber_Subscribe_CanceledDial(t *testing.T) {
+	// Accept connections but never complete the handshake
+	ln, err := (&net.ListenConfig{}).Listen(context.Background(), "tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	ch := make(chan applications.ActivityItem)
+	err = NewSubscriber("ws://"+ln.Addr().String()+"/").Subscribe(ctx, ch)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
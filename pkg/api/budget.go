@@ -0,0 +1,71 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"net/http"
+	"sync"
+)
+
+// retryBudget is a token bucket shared by all requests made through a client which
+// caps the aggregate number of retries, similar to gRPC retry throttling. Each failed
+// request removes a token, each successful request adds back a fraction of a token;
+// retries are only attempted while more than half of the tokens remain.
+type retryBudget struct {
+	mu     sync.Mutex
+	max    float64
+	ratio  float64
+	tokens float64
+}
+
+// newRetryBudget returns a new full budget.
+func newRetryBudget(maxTokens, tokenRatio float64) *retryBudget {
+	return &retryBudget{max: maxTokens, ratio: tokenRatio, tokens: maxTokens}
+}
+
+// allow checks to see if a retry is allowed. A nil budget always allows retries.
+func (b *retryBudget) allow() bool {
+	if b == nil {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tokens > b.max/2
+}
+
+// record updates the budget using the outcome of a request.
+func (b *retryBudget) record(resp *http.Response, err error) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err != nil || resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		b.tokens--
+		if b.tokens < 0 {
+			b.tokens = 0
+		}
+		return
+	}
+
+	b.tokens += b.ratio
+	if b.tokens > b.max {
+		b.tokens = b.max
+	}
+}
@@ -18,10 +18,16 @@ package api
 
 import (
 	"context"
+	"encoding/json"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
+	"strconv"
 	"time"
+
+	"github.com/thestormforge/optimize-go/pkg/api/internal/backoff"
+	"golang.org/x/oauth2"
 )
 
 // Client is used to handle interactions with the API Server.
@@ -32,25 +38,203 @@ type Client interface {
 	Do(context.Context, *http.Request) (*http.Response, []byte, error)
 }
 
+const (
+	// DefaultTimeout is the default time limit for requests, including reading the response body.
+	DefaultTimeout = 10 * time.Second
+	// DefaultDialTimeout is the default time limit for establishing a connection.
+	DefaultDialTimeout = 5 * time.Second
+	// DefaultResponseHeaderTimeout is the default time limit for receiving the response headers.
+	DefaultResponseHeaderTimeout = 10 * time.Second
+	// DefaultRetryDelay is the initial delay used when retrying requests.
+	DefaultRetryDelay = 250 * time.Millisecond
+	// DefaultMaxRetryDelay is the maximum delay used when retrying requests.
+	DefaultMaxRetryDelay = 10 * time.Second
+)
+
+// ClientOption is used to customize the behavior of a client.
+type ClientOption func(*clientOptions)
+
+type clientOptions struct {
+	timeout               time.Duration
+	dialTimeout           time.Duration
+	responseHeaderTimeout time.Duration
+	tokenSource           oauth2.TokenSource
+	strict                bool
+	retryBudget           *retryBudget
+	warningHandler        WarningHandler
+	codecs                []Codec
+	hedgeDelay            time.Duration
+	clock                 Clock
+	maxRetries            int
+}
+
+// WithTimeout sets the overall time limit for requests made by the client.
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(o *clientOptions) { o.timeout = timeout }
+}
+
+// WithDialTimeout sets the time limit for establishing a connection. The dial timeout is
+// only applied when the transport is an `*http.Transport` (including the default transport).
+func WithDialTimeout(timeout time.Duration) ClientOption {
+	return func(o *clientOptions) { o.dialTimeout = timeout }
+}
+
+// WithResponseHeaderTimeout sets the time limit for receiving the response headers. The response
+// header timeout is only applied when the transport is an `*http.Transport` (including the default transport).
+func WithResponseHeaderTimeout(timeout time.Duration) ClientOption {
+	return func(o *clientOptions) { o.responseHeaderTimeout = timeout }
+}
+
+// WithTokenSource authorizes requests using tokens from the supplied source. If the
+// server rejects a token as unauthorized, a new token is obtained from the source and
+// the request is retried once. Tokens are cached by the client, the supplied source
+// should obtain a new token each time it is called (i.e. it should not be wrapped
+// using `oauth2.ReuseTokenSource`).
+func WithTokenSource(src oauth2.TokenSource) ClientOption {
+	return func(o *clientOptions) { o.tokenSource = src }
+}
+
+// WithClock sets the clock used to determine if cached tokens have expired, by default
+// the system clock is used.
+func WithClock(clock Clock) ClientOption {
+	return func(o *clientOptions) { o.clock = clock }
+}
+
+// WithStrictDecoding causes response bodies containing fields unknown to the client to
+// fail decoding. This is useful for detecting API drift during testing, by default
+// unknown fields are ignored. Strict decoding does not apply to registered codecs.
+func WithStrictDecoding() ClientOption {
+	return func(o *clientOptions) { o.strict = true }
+}
+
+// WithRetries retries requests which fail because of a transport error, rate limiting (429)
+// or a temporarily unavailable server (502, 503 and 504) up to the specified number of times.
+// Only idempotent requests whose body can be replayed are retried, retries are delayed using
+// the "Retry-After" header or an exponential backoff. By default requests are not retried.
+func WithRetries(maxRetries int) ClientOption {
+	return func(o *clientOptions) { o.maxRetries = maxRetries }
+}
+
+// WithRetryBudget caps the aggregate number of retries made by the client to avoid
+// overwhelming a recovering server. Each failed request consumes one of the `maxTokens`
+// tokens and each successful request replenishes `tokenRatio` tokens; once half of the
+// tokens have been consumed, requests fail fast without retrying. The budget applies to
+// both the retries enabled by `WithRetries` and the retry of requests with a rejected
+// token. By default retries are not limited.
+func WithRetryBudget(maxTokens, tokenRatio float64) ClientOption {
+	return func(o *clientOptions) { o.retryBudget = newRetryBudget(maxTokens, tokenRatio) }
+}
+
+// WithWarningHandler invokes the supplied handler for each warning returned by the
+// server, for example to let users know they are using a deprecated API.
+func WithWarningHandler(handler WarningHandler) ClientOption {
+	return func(o *clientOptions) { o.warningHandler = handler }
+}
+
+// ConfigureTransport applies the dial and response header timeouts to the supplied
+// transport. Only a plain `*http.Transport` can be reconfigured, anything else (e.g.
+// a transport wrapped for authorization) is returned as-is: callers which wrap the
+// transport should configure the base transport before wrapping it.
+func ConfigureTransport(transport http.RoundTripper, opts ...ClientOption) http.RoundTripper {
+	o := clientOptions{
+		dialTimeout:           DefaultDialTimeout,
+		responseHeaderTimeout: DefaultResponseHeaderTimeout,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o.configureTransport(transport)
+}
+
+// configureTransport returns a copy of a plain HTTP transport with the timeouts applied.
+func (o *clientOptions) configureTransport(transport http.RoundTripper) http.RoundTripper {
+	t, ok := transport.(*http.Transport)
+	if !ok {
+		return transport
+	}
+
+	t = t.Clone()
+	t.DialContext = (&net.Dialer{Timeout: o.dialTimeout, KeepAlive: 30 * time.Second}).DialContext
+	t.ResponseHeaderTimeout = o.responseHeaderTimeout
+	return t
+}
+
 // NewClient returns a new client for accessing API server.
-func NewClient(address string, transport http.RoundTripper) (Client, error) {
+func NewClient(address string, transport http.RoundTripper, opts ...ClientOption) (Client, error) {
 	u, err := url.Parse(address)
 	if err != nil {
 		return nil, err
 	}
 
+	o := clientOptions{
+		timeout:               DefaultTimeout,
+		dialTimeout:           DefaultDialTimeout,
+		responseHeaderTimeout: DefaultResponseHeaderTimeout,
+		clock:                 SystemClock,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	transport = o.configureTransport(transport)
+
+	// Authorize requests to the server using a token source that can be forced to refresh
+	var tokens *refreshableTokenSource
+	if o.tokenSource != nil {
+		tokens = &refreshableTokenSource{src: o.tokenSource, clock: o.clock}
+		transport = &authorizationTransport{
+			Transport: oauth2.Transport{Source: tokens, Base: transport},
+			Server:    u,
+		}
+	}
+
 	return &httpClient{
 		client: http.Client{
 			Transport: transport,
-			Timeout:   10 * time.Second, // TODO This should be configurable, e.g. for debugging
+			Timeout:   o.timeout,
+		},
+		base:    *u,
+		tokens:  tokens,
+		strict:  o.strict,
+		budget:  o.retryBudget,
+		warn:    o.warningHandler,
+		codecs:  o.codecs,
+		hedge:   o.hedgeDelay,
+		retries: o.maxRetries,
+		retryBackoff: backoff.Backoff{
+			Base: DefaultRetryDelay,
+			Max:  DefaultMaxRetryDelay,
 		},
-		base: *u,
 	}, nil
 }
 
 type httpClient struct {
 	client http.Client
 	base   url.URL
+	tokens *refreshableTokenSource
+	strict bool
+	budget *retryBudget
+	warn   WarningHandler
+	codecs []Codec
+	hedge  time.Duration
+
+	retries      int
+	retryBackoff backoff.Backoff
+}
+
+// StreamingClient returns an HTTP client suitable for long-lived streaming responses, which
+// cannot be buffered by `Client.Do`. The returned client shares the transport of the supplied
+// client (including authorization and connection timeouts) but does not impose an overall
+// time limit. If the supplied client is not backed by an HTTP client, the default HTTP
+// client is returned.
+func StreamingClient(c Client) *http.Client {
+	if hc, ok := c.(*httpClient); ok {
+		return &http.Client{Transport: hc.client.Transport}
+	}
+	return http.DefaultClient
 }
 
 // URL resolves an endpoint to a fully qualified URL.
@@ -68,13 +252,123 @@ func (c *httpClient) URL(ep string) *url.URL {
 func (c *httpClient) Do(ctx context.Context, req *http.Request) (*http.Response, []byte, error) {
 	if ctx != nil {
 		req = req.WithContext(ctx)
+	} else {
+		ctx = req.Context()
+	}
+	req = c.overrideEndpoint(ctx, req)
+	if accept := c.accept(); accept != "" && req.Header.Get("Accept") == "" {
+		req = req.Clone(ctx)
+		req.Header.Set("Accept", accept)
 	}
+
+	resp, body, err := c.hedged(ctx, req)
+	c.budget.record(resp, err)
+
+	// Retry requests which failed because the server is unavailable, backing off between attempts
+	b := c.retryBackoff
+	for b.Attempt() < c.retries && retryable(ctx, req, resp, err) && c.budget.allow() {
+		if err := sleep(ctx, retryDelay(resp, &b)); err != nil {
+			return nil, nil, err
+		}
+
+		retry, rerr := replay(ctx, req)
+		if rerr != nil {
+			return nil, nil, rerr
+		}
+
+		resp, body, err = c.hedged(ctx, retry)
+		c.budget.record(resp, err)
+	}
+
+	// Retry unauthorized requests exactly once using a freshly obtained token
+	if err == nil && resp.StatusCode == http.StatusUnauthorized && c.tokens != nil && sameHost(req.URL, &c.base) && (req.Body == nil || req.GetBody != nil) && c.budget.allow() {
+		retry, rerr := replay(ctx, req)
+		if rerr != nil {
+			return nil, nil, rerr
+		}
+
+		c.tokens.Invalidate()
+		resp, body, err = c.hedged(ctx, retry)
+		c.budget.record(resp, err)
+	}
+
+	return resp, body, err
+}
+
+// replay returns a copy of the request which can be sent again.
+func replay(ctx context.Context, req *http.Request) (*http.Request, error) {
+	retry := req.Clone(ctx)
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		retry.Body = body
+	}
+	return retry, nil
+}
+
+// retryable checks to see if the outcome of a request indicates it should be retried.
+func retryable(ctx context.Context, req *http.Request, resp *http.Response, err error) bool {
+	if ctx.Err() != nil || (req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) {
+		return false
+	}
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+	default:
+		return false
+	}
+
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// retryDelay returns the amount of time to wait before retrying a request, honoring
+// the server's "Retry-After" header (in seconds) if it is present.
+func retryDelay(resp *http.Response, b *backoff.Backoff) time.Duration {
+	d := b.Next()
+	if resp != nil {
+		if ra, _ := strconv.Atoi(resp.Header.Get("Retry-After")); ra > 0 {
+			d = time.Duration(ra) * time.Second
+		}
+	}
+	return d
+}
+
+// sleep waits for the specified duration or until the context is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// do executes a single HTTP request, buffering the response body.
+func (c *httpClient) do(ctx context.Context, req *http.Request) (*http.Response, []byte, error) {
 	resp, err := c.client.Do(req)
 	if err != nil {
 		return nil, nil, err
 	}
 	defer resp.Body.Close()
 
+	if c.warn != nil {
+		for _, w := range ParseWarnings(resp.Header) {
+			c.warn(w)
+		}
+	}
+
 	var body []byte
 	done := make(chan struct{})
 	go func() {
@@ -94,3 +388,22 @@ func (c *httpClient) Do(ctx context.Context, req *http.Request) (*http.Response,
 
 	return resp, body, err
 }
+
+// Unmarshal decodes a response body obtained using this client.
+func (c *httpClient) Unmarshal(body []byte, v interface{}) error {
+	if err := json.Unmarshal(body, v); err != nil || !c.strict {
+		return err
+	}
+	return checkUnknownFields(body, v)
+}
+
+// UnmarshalBody decodes a response body obtained using the supplied client, honoring
+// the decoding preferences of the client.
+func UnmarshalBody(c Client, body []byte, v interface{}) error {
+	if u, ok := c.(interface {
+		Unmarshal([]byte, interface{}) error
+	}); ok {
+		return u.Unmarshal(body, v)
+	}
+	return json.Unmarshal(body, v)
+}
@@ -17,9 +17,19 @@ limitations under the License.
 package api
 
 import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
 )
 
 func TestHttpClient_URL(t *testing.T) {
@@ -68,3 +78,597 @@ func TestHttpClient_URL(t *testing.T) {
 		})
 	}
 }
+
+func TestHttpClient_Do_ResponseHeaderTimeout(t *testing.T) {
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer srv.Close()
+	defer close(done)
+
+	client, err := NewClient(srv.URL, nil, WithResponseHeaderTimeout(50*time.Millisecond))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	req, err := http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, _, err = client.Do(context.Background(), req)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "timeout awaiting response headers")
+	}
+}
+
+func TestConfigureTransport(t *testing.T) {
+	transport := ConfigureTransport(&http.Transport{}, WithResponseHeaderTimeout(50*time.Millisecond))
+	if ht, ok := transport.(*http.Transport); assert.True(t, ok) {
+		assert.Equal(t, 50*time.Millisecond, ht.ResponseHeaderTimeout)
+		assert.NotNil(t, ht.DialContext)
+	}
+
+	// Wrapped transports cannot be reconfigured
+	wrapped := &oauth2.Transport{Base: http.DefaultTransport}
+	assert.Same(t, wrapped, ConfigureTransport(wrapped))
+}
+
+func TestHttpClient_Do_WrappedResponseHeaderTimeout(t *testing.T) {
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer srv.Close()
+	defer close(done)
+
+	// Configure the base transport before it is wrapped, as the CLI does
+	base := ConfigureTransport(http.DefaultTransport, WithResponseHeaderTimeout(50*time.Millisecond))
+	transport := &oauth2.Transport{Source: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "test"}), Base: base}
+	client, err := NewClient(srv.URL, transport)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	req, err := http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, _, err = client.Do(context.Background(), req)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "timeout awaiting response headers")
+	}
+}
+
+// countingTokenSource returns a new token each time it is called.
+type countingTokenSource int
+
+func (ts *countingTokenSource) Token() (*oauth2.Token, error) {
+	*ts++
+	return &oauth2.Token{AccessToken: fmt.Sprintf("token-%d", *ts)}, nil
+}
+
+func TestHttpClient_Do_RefreshToken(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, "payload", string(body))
+		if r.Header.Get("Authorization") != "Bearer token-2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	ts := countingTokenSource(0)
+	client, err := NewClient(srv.URL, nil, WithTokenSource(&ts))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	req, err := http.NewRequest(http.MethodPost, client.URL("/").String(), strings.NewReader("payload"))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	resp, _, err := client.Do(context.Background(), req)
+	if assert.NoError(t, err) {
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		assert.Equal(t, 2, requests)
+		assert.Equal(t, countingTokenSource(2), ts)
+	}
+
+	// The refreshed token is reused, a persistent failure is only retried once
+	req, _ = http.NewRequest(http.MethodPost, client.URL("/").String(), strings.NewReader("payload"))
+	_, _, err = client.Do(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, requests)
+
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	req, _ = http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+	resp, _, err = client.Do(context.Background(), req)
+	if assert.NoError(t, err) {
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.Equal(t, 5, requests)
+	}
+}
+
+func TestHttpClient_Do_ExpiredToken(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("Authorization") != "Bearer token-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	ts := countingTokenSource(0)
+	now := time.Date(2022, time.March, 1, 12, 0, 0, 0, time.UTC)
+	client, err := NewClient(srv.URL, nil, WithTokenSource(&ts), WithClock(FixedClock(now)))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// Cache an expired JWT without an explicit expiry
+	enc := base64.RawURLEncoding.EncodeToString
+	claims := fmt.Sprintf(`{"exp": %d}`, now.Add(-time.Minute).Unix())
+	expired := enc([]byte(`{"alg":"HS256"}`)) + "." + enc([]byte(claims)) + "." + enc([]byte("sig"))
+	client.(*httpClient).tokens.tok = &oauth2.Token{AccessToken: expired}
+
+	req, err := http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	resp, _, err := client.Do(context.Background(), req)
+	if assert.NoError(t, err) {
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		assert.Equal(t, 1, requests)
+		assert.Equal(t, countingTokenSource(1), ts)
+	}
+}
+
+func TestRefreshableTokenSource_Clock(t *testing.T) {
+	now := time.Date(2022, time.March, 1, 12, 0, 0, 0, time.UTC)
+	enc := base64.RawURLEncoding.EncodeToString
+	jwtExpiringAt := func(exp time.Time) string {
+		claims := fmt.Sprintf(`{"exp": %d}`, exp.Unix())
+		return enc([]byte(`{"alg":"HS256"}`)) + "." + enc([]byte(claims)) + "." + enc([]byte("sig"))
+	}
+
+	cases := []struct {
+		desc    string
+		tok     *oauth2.Token
+		refresh bool
+	}{
+		{
+			desc: "valid expiry",
+			tok:  &oauth2.Token{AccessToken: "cached", Expiry: now.Add(time.Minute)},
+		},
+		{
+			desc:    "expired expiry",
+			tok:     &oauth2.Token{AccessToken: "cached", Expiry: now.Add(-time.Minute)},
+			refresh: true,
+		},
+		{
+			desc:    "expiry within delta",
+			tok:     &oauth2.Token{AccessToken: "cached", Expiry: now.Add(expiryDelta / 2)},
+			refresh: true,
+		},
+		{
+			desc: "valid claim",
+			tok:  &oauth2.Token{AccessToken: jwtExpiringAt(now.Add(time.Minute))},
+		},
+		{
+			desc:    "expired claim",
+			tok:     &oauth2.Token{AccessToken: jwtExpiringAt(now.Add(-time.Minute))},
+			refresh: true,
+		},
+		{
+			desc: "opaque",
+			tok:  &oauth2.Token{AccessToken: "cached"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			ts := countingTokenSource(0)
+			s := &refreshableTokenSource{src: &ts, clock: FixedClock(now), tok: c.tok}
+			tok, err := s.Token()
+			if assert.NoError(t, err) {
+				if c.refresh {
+					assert.Equal(t, "token-1", tok.AccessToken)
+				} else {
+					assert.Same(t, c.tok, tok)
+				}
+			}
+		})
+	}
+}
+
+func TestUnmarshalBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"name": "test", "addedLater": true}`))
+	}))
+	defer srv.Close()
+
+	cases := []struct {
+		desc string
+		opts []ClientOption
+		err  bool
+	}{
+		{
+			desc: "lenient",
+		},
+		{
+			desc: "strict",
+			opts: []ClientOption{WithStrictDecoding()},
+			err:  true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			client, err := NewClient(srv.URL, nil, c.opts...)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			req, err := http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			_, body, err := client.Do(context.Background(), req)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			result := struct {
+				Name string `json:"name"`
+			}{}
+			err = UnmarshalBody(client, body, &result)
+			if c.err {
+				assert.ErrorContains(t, err, "addedLater")
+			} else if assert.NoError(t, err) {
+				assert.Equal(t, "test", result.Name)
+			}
+		})
+	}
+}
+
+func TestHttpClient_Do_RetryBudget(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	ts := countingTokenSource(0)
+	client, err := NewClient(srv.URL, nil, WithTokenSource(&ts), WithRetryBudget(4, 0.5))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// Each failure consumes a token, retries stop once half the budget is gone
+	var perRequest []int
+	for i := 0; i < 4; i++ {
+		requests = 0
+		req, _ := http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+		resp, _, err := client.Do(context.Background(), req)
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		}
+		perRequest = append(perRequest, requests)
+	}
+	assert.Equal(t, []int{2, 1, 1, 1}, perRequest)
+
+	// Successful requests replenish the budget
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	for i := 0; i < 8; i++ {
+		req, _ := http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+		_, _, err := client.Do(context.Background(), req)
+		assert.NoError(t, err)
+	}
+
+	requests = 0
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	req, _ := http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+	_, _, err = client.Do(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, requests)
+}
+
+func TestHttpClient_Do_Retries(t *testing.T) {
+	cases := []struct {
+		desc     string
+		method   string
+		statuses []int
+		status   int
+		requests int
+	}{
+		{
+			desc:     "recovers",
+			method:   http.MethodGet,
+			statuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests},
+			status:   http.StatusNoContent,
+			requests: 3,
+		},
+		{
+			desc:     "exhausted",
+			method:   http.MethodGet,
+			statuses: []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway},
+			status:   http.StatusBadGateway,
+			requests: 3,
+		},
+		{
+			desc:     "not idempotent",
+			method:   http.MethodPost,
+			statuses: []int{http.StatusServiceUnavailable},
+			status:   http.StatusServiceUnavailable,
+			requests: 1,
+		},
+		{
+			desc:     "not retryable",
+			method:   http.MethodGet,
+			statuses: []int{http.StatusInternalServerError},
+			status:   http.StatusInternalServerError,
+			requests: 1,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			var requests int
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if requests <= len(c.statuses) {
+					w.WriteHeader(c.statuses[requests-1])
+					return
+				}
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer srv.Close()
+
+			client, err := NewClient(srv.URL, nil, WithRetries(2))
+			if !assert.NoError(t, err) {
+				return
+			}
+			client.(*httpClient).retryBackoff.Base = time.Millisecond
+			client.(*httpClient).retryBackoff.Max = time.Millisecond
+
+			req, _ := http.NewRequest(c.method, client.URL("/").String(), nil)
+			resp, _, err := client.Do(context.Background(), req)
+			if assert.NoError(t, err) {
+				assert.Equal(t, c.status, resp.StatusCode)
+				assert.Equal(t, c.requests, requests)
+			}
+		})
+	}
+}
+
+func TestHttpClient_Do_RetryBudget_Unavailable(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(srv.URL, nil, WithRetries(3), WithRetryBudget(10, 0.1))
+	if !assert.NoError(t, err) {
+		return
+	}
+	client.(*httpClient).retryBackoff.Base = time.Millisecond
+	client.(*httpClient).retryBackoff.Max = time.Millisecond
+
+	// Failing requests deplete the budget, subsequent requests are not retried
+	var perRequest []int
+	for i := 0; i < 3; i++ {
+		requests = 0
+		req, _ := http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+		resp, _, err := client.Do(context.Background(), req)
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		}
+		perRequest = append(perRequest, requests)
+	}
+	assert.Equal(t, []int{4, 1, 1}, perRequest)
+}
+
+func TestHttpClient_Do_Warnings(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Warning", `299 - "This API is deprecated, use v2", 199 api.example.com "Slow \"down\"" "Wed, 21 Oct 2015 07:28:00 GMT"`)
+		w.Header().Add("Warning", `299 - "Another warning"`)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	var warnings []Warning
+	client, err := NewClient(srv.URL, nil, WithWarningHandler(func(w Warning) { warnings = append(warnings, w) }))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	req, err := http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	resp, _, err := client.Do(context.Background(), req)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	expected := []Warning{
+		{Code: 299, Agent: "-", Text: "This API is deprecated, use v2"},
+		{Code: 199, Agent: "api.example.com", Text: `Slow "down"`},
+		{Code: 299, Agent: "-", Text: "Another warning"},
+	}
+	assert.Equal(t, expected, warnings)
+
+	var md Metadata
+	UnmarshalMetadata(resp, &md)
+	assert.Equal(t, expected, md.Warnings())
+}
+
+func TestHttpClient_Do_EndpointOverride(t *testing.T) {
+	var primary, canary []string
+	primarySrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primary = append(primary, r.URL.RequestURI())
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer primarySrv.Close()
+	canarySrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		canary = append(canary, r.URL.RequestURI())
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer canarySrv.Close()
+
+	client, err := NewClient(primarySrv.URL+"/v1/", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	ctx, err := WithEndpointOverride(context.Background(), canarySrv.URL+"/canary")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for _, c := range []context.Context{context.Background(), ctx} {
+		req, err := http.NewRequest(http.MethodGet, client.URL("experiments/?limit=1").String(), nil)
+		if assert.NoError(t, err) {
+			_, _, err = client.Do(c, req)
+			assert.NoError(t, err)
+		}
+	}
+
+	assert.Equal(t, []string{"/v1/experiments/?limit=1"}, primary)
+	assert.Equal(t, []string{"/canary/experiments/?limit=1"}, canary)
+
+	for _, invalid := range []string{"", "canary.example.com", "ftp://canary.example.com/", "https://canary.example.com/?x=1", "https://canary.example.com:port/"} {
+		_, err := WithEndpointOverride(context.Background(), invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestHttpClient_Do_EndpointOverride_Authorization(t *testing.T) {
+	var auth []string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = append(auth, r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	primarySrv := httptest.NewServer(handler)
+	defer primarySrv.Close()
+	foreignSrv := httptest.NewServer(handler)
+	defer foreignSrv.Close()
+
+	client, err := NewClient(primarySrv.URL+"/v1/", nil, WithTokenSource(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "secret"})))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	cases := []struct {
+		desc     string
+		override string
+		auth     string
+	}{
+		{desc: "same host", override: primarySrv.URL + "/canary", auth: "Bearer secret"},
+		{desc: "foreign host", override: foreignSrv.URL + "/canary"},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			auth = nil
+			ctx, err := WithEndpointOverride(context.Background(), c.override)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			req, err := http.NewRequest(http.MethodGet, client.URL("experiments/").String(), nil)
+			if assert.NoError(t, err) {
+				_, _, err = client.Do(ctx, req)
+				assert.NoError(t, err)
+				assert.Equal(t, []string{c.auth}, auth)
+			}
+		})
+	}
+}
+
+func TestHttpClient_Do_Hedging(t *testing.T) {
+	var mu sync.Mutex
+	var requests int
+	canceled := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		n := requests
+		mu.Unlock()
+
+		// The first request is slow, it should be canceled once the hedged request wins
+		if n == 1 {
+			select {
+			case <-r.Context().Done():
+				close(canceled)
+			case <-time.After(5 * time.Second):
+			}
+			return
+		}
+		_, _ = fmt.Fprint(w, "fast")
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(srv.URL, nil, WithHedging(50*time.Millisecond))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	req, err := http.NewRequest(http.MethodGet, client.URL("/").String(), nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, body, err := client.Do(context.Background(), req)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "fast", string(body))
+		assert.Equal(t, 2, requests)
+	}
+
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		assert.Fail(t, "slow request was not canceled")
+	}
+
+	// Unsafe methods are never hedged
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		mu.Unlock()
+		time.Sleep(100 * time.Millisecond)
+	})
+	req, _ = http.NewRequest(http.MethodPost, client.URL("/").String(), strings.NewReader("payload"))
+	_, _, err = client.Do(context.Background(), req)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 3, requests)
+	}
+}
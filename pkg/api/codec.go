@@ -0,0 +1,90 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// Codec is used to decode an alternate (non-JSON) content type.
+type Codec interface {
+	// ContentType returns the media type handled by the codec.
+	ContentType() string
+	// Unmarshal decodes the supplied data into a value.
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// WithCodec registers an alternate codec with the client. Requests which do not specify
+// an "Accept" header will advertise the registered content types (preferring them over
+// JSON), servers that do not support them will continue to respond with JSON. Request
+// bodies are always sent as JSON.
+func WithCodec(codec Codec) ClientOption {
+	return func(o *clientOptions) { o.codecs = append(o.codecs, codec) }
+}
+
+// accept returns the value of the "Accept" header to use for content negotiation.
+func (c *httpClient) accept() string {
+	if len(c.codecs) == 0 {
+		return ""
+	}
+
+	accept := make([]string, 0, len(c.codecs)+1)
+	for _, codec := range c.codecs {
+		accept = append(accept, codec.ContentType())
+	}
+	return strings.Join(append(accept, "application/json;q=0.9"), ", ")
+}
+
+// codec returns the registered codec for the response, if any.
+func (c *httpClient) codec(resp *http.Response) Codec {
+	if resp == nil || len(c.codecs) == 0 {
+		return nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil
+	}
+	for _, codec := range c.codecs {
+		if strings.EqualFold(codec.ContentType(), mediaType) {
+			return codec
+		}
+	}
+	return nil
+}
+
+// UnmarshalResponse decodes a response body using the registered codec matching the
+// response content type, falling back to JSON.
+func (c *httpClient) UnmarshalResponse(resp *http.Response, body []byte, v interface{}) error {
+	if codec := c.codec(resp); codec != nil {
+		return codec.Unmarshal(body, v)
+	}
+	return c.Unmarshal(body, v)
+}
+
+// UnmarshalResponse decodes a response obtained using the supplied client, honoring
+// the content type of the response and the decoding preferences of the client.
+func UnmarshalResponse(c Client, resp *http.Response, body []byte, v interface{}) error {
+	if u, ok := c.(interface {
+		UnmarshalResponse(*http.Response, []byte, interface{}) error
+	}); ok {
+		return u.UnmarshalResponse(resp, body, v)
+	}
+	return UnmarshalBody(c, body, v)
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type endpointOverrideKey struct{}
+
+// WithEndpointOverride returns a context that sends requests to an alternate base URL
+// (for example, a canary deployment). Only requests for URLs that were resolved against
+// the client's configured address are redirected; the remainder of the URL is preserved.
+// The client's token is only sent to an override with the same scheme and host as the
+// client's address, credentials for any other host must come from the client's transport.
+func WithEndpointOverride(ctx context.Context, baseURL string) (context.Context, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return ctx, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return ctx, fmt.Errorf("invalid endpoint override %q, must be an absolute HTTP URL", baseURL)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return ctx, fmt.Errorf("invalid endpoint override %q, must not include a query or fragment", baseURL)
+	}
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+		u.RawPath = ""
+	}
+	return context.WithValue(ctx, endpointOverrideKey{}, u), nil
+}
+
+// endpointOverride returns the overridden base URL from the context, if present.
+func endpointOverride(ctx context.Context) (*url.URL, bool) {
+	u, ok := ctx.Value(endpointOverrideKey{}).(*url.URL)
+	return u, ok
+}
+
+// overrideEndpoint rewrites the request URL if the context specifies an alternate base URL.
+func (c *httpClient) overrideEndpoint(ctx context.Context, req *http.Request) *http.Request {
+	override, ok := endpointOverride(ctx)
+	if !ok {
+		return req
+	}
+
+	base := c.base.String()
+	if !strings.HasSuffix(base, "/") {
+		base += "/"
+	}
+	rel := strings.TrimPrefix(req.URL.String(), base)
+	if rel == req.URL.String() {
+		return req
+	}
+
+	u, err := override.Parse(rel)
+	if err != nil {
+		return req
+	}
+
+	req = req.Clone(ctx)
+	req.URL = u
+	req.Host = ""
+	return req
+}
@@ -27,11 +27,13 @@ const (
 	ErrExperimentNameConflict api.ErrorType = "experiment-name-conflict"
 	ErrExperimentInvalid      api.ErrorType = "experiment-invalid"
 	ErrExperimentNotFound     api.ErrorType = "experiment-not-found"
+	ErrExperimentNotModified  api.ErrorType = "experiment-not-modified"
 	ErrExperimentStopped      api.ErrorType = "experiment-stopped"
 	ErrTrialInvalid           api.ErrorType = "trial-invalid"
 	ErrTrialUnavailable       api.ErrorType = "trial-unavailable"
 	ErrTrialNotFound          api.ErrorType = "trial-not-found"
 	ErrTrialAlreadyReported   api.ErrorType = "trial-already-reported"
+	ErrNotSupported           api.ErrorType = "not-supported"
 )
 
 type Server struct {
@@ -47,15 +49,20 @@ type API interface {
 	GetAllExperimentsByPage(context.Context, string) (ExperimentList, error)
 	GetExperimentByName(context.Context, ExperimentName) (Experiment, error)
 	GetExperiment(context.Context, string) (Experiment, error)
+	GetExperimentIfModified(context.Context, string, string) (Experiment, error)
 	CreateExperimentByName(context.Context, ExperimentName, Experiment) (Experiment, error)
 	CreateExperiment(context.Context, string, Experiment) (Experiment, error)
+	PatchExperiment(context.Context, string, ExperimentPatch) (Experiment, error)
 	DeleteExperiment(context.Context, string) error
 	LabelExperiment(context.Context, string, ExperimentLabels) error
 
 	GetAllTrials(context.Context, string, TrialListQuery) (TrialList, error)
 	CreateTrial(context.Context, string, TrialAssignments) (TrialAssignments, error)
 	NextTrial(context.Context, string) (TrialAssignments, error)
+	PeekNextTrial(context.Context, string) (TrialAssignments, error)
 	ReportTrial(context.Context, string, TrialValues) error
+	UpdateTrialValues(context.Context, string, []Value) error
+	FinalizeTrial(context.Context, string, TrialValues) error
 	AbandonRunningTrial(context.Context, string) error
 	LabelTrial(context.Context, string, TrialLabels) error
 }
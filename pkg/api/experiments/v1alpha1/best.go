@@ -0,0 +1,146 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import "math"
+
+// ObjectiveMetric returns the named metric from the experiment. If the name is empty,
+// the first optimized metric is returned. The returned boolean is false if no metric
+// could be found.
+func ObjectiveMetric(exp *Experiment, name string) (Metric, bool) {
+	for _, m := range exp.Metrics {
+		if name != "" && m.Name == name {
+			return m, true
+		}
+		if name == "" && (m.Optimize == nil || *m.Optimize) {
+			return m, true
+		}
+	}
+	return Metric{}, false
+}
+
+// BestTrial returns the completed trial with the best value for the supplied metric,
+// honoring the metric's direction of optimization. Failed trials and trials which do
+// not report the metric are ignored. The returned boolean is false if there are no
+// eligible trials; ties are resolved in favor of the lowest trial number.
+func BestTrial(trials []TrialItem, metric Metric) (*TrialItem, bool) {
+	var best *TrialItem
+	var bestValue float64
+	for i := range trials {
+		t := &trials[i]
+		if t.Status != TrialCompleted || t.Failed {
+			continue
+		}
+
+		for _, v := range t.Values {
+			if v.MetricName != metric.Name {
+				continue
+			}
+
+			better := best == nil ||
+				(metric.Minimize && v.Value < bestValue) ||
+				(!metric.Minimize && v.Value > bestValue) ||
+				(v.Value == bestValue && t.Number < best.Number)
+			if better {
+				best, bestValue = t, v.Value
+			}
+			break
+		}
+	}
+	return best, best != nil
+}
+
+// BestWeightedTrial returns the completed trial with the best weighted score across the
+// supplied metrics. Each metric value is normalized to the range of values reported by the
+// eligible trials (so metrics with different units can be compared) and oriented using the
+// metric's direction of optimization before being multiplied by its weight. Metrics without
+// a positive weight are ignored. Failed trials and trials which do not report every weighted
+// metric are ignored. The returned boolean is false if there are no eligible trials; ties are
+// resolved in favor of the lowest trial number.
+func BestWeightedTrial(trials []TrialItem, metrics []Metric, weights map[string]float64) (*TrialItem, bool) {
+	var weighted []Metric
+	for _, m := range metrics {
+		if weights[m.Name] > 0 {
+			weighted = append(weighted, m)
+		}
+	}
+	if len(weighted) == 0 {
+		return nil, false
+	}
+
+	// Collect the values of the eligible trials and the range of each metric
+	type candidate struct {
+		trial  *TrialItem
+		values []float64
+	}
+	var candidates []candidate
+	lo, hi := make([]float64, len(weighted)), make([]float64, len(weighted))
+	for i := range weighted {
+		lo[i], hi[i] = math.Inf(1), math.Inf(-1)
+	}
+	for i := range trials {
+		t := &trials[i]
+		if t.Status != TrialCompleted || t.Failed {
+			continue
+		}
+
+		c := candidate{trial: t, values: make([]float64, len(weighted))}
+		found := 0
+		for j, m := range weighted {
+			for _, v := range t.Values {
+				if v.MetricName == m.Name {
+					c.values[j] = v.Value
+					found++
+					break
+				}
+			}
+		}
+		if found != len(weighted) {
+			continue
+		}
+
+		for j, v := range c.values {
+			lo[j], hi[j] = math.Min(lo[j], v), math.Max(hi[j], v)
+		}
+		candidates = append(candidates, c)
+	}
+
+	// Lower scores are better: each normalized value is 0 at the best observed value
+	var best *TrialItem
+	var bestScore float64
+	for _, c := range candidates {
+		var score float64
+		for j, m := range weighted {
+			if hi[j] == lo[j] {
+				continue
+			}
+			n := (c.values[j] - lo[j]) / (hi[j] - lo[j])
+			if !m.Minimize {
+				n = 1 - n
+			}
+			score += weights[m.Name] * n
+		}
+
+		better := best == nil ||
+			score < bestScore ||
+			(score == bestScore && c.trial.Number < best.Number)
+		if better {
+			best, bestScore = c.trial, score
+		}
+	}
+	return best, best != nil
+}
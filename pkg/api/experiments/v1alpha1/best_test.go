@@ -0,0 +1,141 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBestTrial(t *testing.T) {
+	trials := []TrialItem{
+		{Number: 1, Status: TrialCompleted, TrialValues: TrialValues{Values: []Value{{MetricName: "cost", Value: 10}, {MetricName: "throughput", Value: 100}}}},
+		{Number: 2, Status: TrialCompleted, TrialValues: TrialValues{Values: []Value{{MetricName: "cost", Value: 5}, {MetricName: "throughput", Value: 80}}}},
+		{Number: 3, Status: TrialFailed, TrialValues: TrialValues{Failed: true}},
+		{Number: 4, Status: TrialActive},
+		{Number: 5, Status: TrialCompleted, TrialValues: TrialValues{Values: []Value{{MetricName: "cost", Value: 5}, {MetricName: "throughput", Value: 120}}}},
+	}
+
+	cases := []struct {
+		desc     string
+		metric   Metric
+		expected int64
+	}{
+		{
+			desc:     "minimize with tie",
+			metric:   Metric{Name: "cost", Minimize: true},
+			expected: 2,
+		},
+		{
+			desc:     "maximize",
+			metric:   Metric{Name: "throughput"},
+			expected: 5,
+		},
+		{
+			desc:   "unknown metric",
+			metric: Metric{Name: "latency"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			best, ok := BestTrial(trials, c.metric)
+			if c.expected == 0 {
+				assert.False(t, ok)
+			} else if assert.True(t, ok) {
+				assert.Equal(t, c.expected, best.Number)
+			}
+		})
+	}
+}
+
+func TestObjectiveMetric(t *testing.T) {
+	no := false
+	exp := &Experiment{Metrics: []Metric{
+		{Name: "duration", Optimize: &no},
+		{Name: "cost", Minimize: true},
+	}}
+
+	m, ok := ObjectiveMetric(exp, "")
+	if assert.True(t, ok) {
+		assert.Equal(t, "cost", m.Name)
+	}
+
+	m, ok = ObjectiveMetric(exp, "duration")
+	if assert.True(t, ok) {
+		assert.Equal(t, "duration", m.Name)
+	}
+
+	_, ok = ObjectiveMetric(exp, "latency")
+	assert.False(t, ok)
+}
+
+func TestBestWeightedTrial(t *testing.T) {
+	metrics := []Metric{
+		{Name: "cost", Minimize: true},
+		{Name: "throughput"},
+	}
+	trials := []TrialItem{
+		{Number: 1, Status: TrialCompleted, TrialValues: TrialValues{Values: []Value{{MetricName: "cost", Value: 10}, {MetricName: "throughput", Value: 100}}}},
+		{Number: 2, Status: TrialCompleted, TrialValues: TrialValues{Values: []Value{{MetricName: "cost", Value: 20}, {MetricName: "throughput", Value: 190}}}},
+		{Number: 3, Status: TrialCompleted, TrialValues: TrialValues{Values: []Value{{MetricName: "cost", Value: 30}, {MetricName: "throughput", Value: 200}}}},
+		{Number: 4, Status: TrialFailed, TrialValues: TrialValues{Failed: true}},
+		{Number: 5, Status: TrialCompleted, TrialValues: TrialValues{Values: []Value{{MetricName: "cost", Value: 1}}}},
+		{Number: 6, Status: TrialCompleted, TrialValues: TrialValues{Values: []Value{{MetricName: "cost", Value: 20}, {MetricName: "throughput", Value: 190}}}},
+	}
+
+	cases := []struct {
+		desc     string
+		weights  map[string]float64
+		expected int64
+	}{
+		{
+			desc:     "cost only",
+			weights:  map[string]float64{"cost": 1},
+			expected: 5,
+		},
+		{
+			desc:     "throughput only",
+			weights:  map[string]float64{"throughput": 1},
+			expected: 3,
+		},
+		{
+			desc:     "balanced with tie",
+			weights:  map[string]float64{"cost": 1, "throughput": 1},
+			expected: 2,
+		},
+		{
+			desc:     "favor cost",
+			weights:  map[string]float64{"cost": 3, "throughput": 1},
+			expected: 1,
+		},
+		{
+			desc:    "no weights",
+			weights: map[string]float64{"latency": 1},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			best, ok := BestWeightedTrial(trials, metrics, c.weights)
+			if c.expected == 0 {
+				assert.False(t, ok)
+			} else if assert.True(t, ok) {
+				assert.Equal(t, c.expected, best.Number)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/thestormforge/optimize-go/pkg/api"
+)
+
+// CloneExperiment reads the definition of the source experiment and creates it using the
+// destination API (which may be for a different server). If requested, the completed trials
+// of the source experiment are recreated and reported on the destination experiment.
+func CloneExperiment(ctx context.Context, srcAPI API, srcName ExperimentName, dstAPI API, dstName ExperimentName, copyTrials bool) (Experiment, error) {
+	src, err := srcAPI.GetExperimentByName(ctx, srcName)
+	if err != nil {
+		return Experiment{}, err
+	}
+
+	// Only copy the definition, server generated state does not carry over
+	def := Experiment{
+		DisplayName:  src.DisplayName,
+		Budget:       src.Budget,
+		Optimization: src.Optimization,
+		Metrics:      src.Metrics,
+		Constraints:  src.Constraints,
+		Parameters:   src.Parameters,
+		Labels:       src.Labels,
+	}
+
+	dst, err := dstAPI.CreateExperimentByName(ctx, dstName, def)
+	if err != nil {
+		return Experiment{}, err
+	}
+	if dst.Name == "" {
+		dst.Name = dstName
+	}
+
+	if !copyTrials {
+		return dst, nil
+	}
+
+	// Make sure the trials will be valid for the destination experiment
+	if err := checkCompatible(&src, &dst); err != nil {
+		return dst, err
+	}
+
+	var trials []TrialItem
+	q := TrialListQuery{}
+	q.SetStatus(TrialCompleted)
+	l := Lister{API: srcAPI}
+	if err := l.ForEachTrial(ctx, &src, q, func(item *TrialItem) error {
+		if item.Status == TrialCompleted {
+			trials = append(trials, *item)
+		}
+		return nil
+	}); err != nil {
+		return dst, err
+	}
+	sort.Slice(trials, func(i, j int) bool { return trials[i].Number < trials[j].Number })
+
+	trialsURL, nextTrialURL := dst.Link(api.RelationTrials), dst.Link(api.RelationNextTrial)
+	if trialsURL == "" || nextTrialURL == "" {
+		return dst, fmt.Errorf("malformed response, missing trials link")
+	}
+
+	for i := range trials {
+		if err := checkAssignments(&dst, trials[i].Assignments); err != nil {
+			return dst, fmt.Errorf("unable to copy trial %d: %w", trials[i].Number, err)
+		}
+
+		// Explicitly created trials are the next trials to be returned
+		if _, err := dstAPI.CreateTrial(ctx, trialsURL, TrialAssignments{
+			Assignments: trials[i].Assignments,
+			Labels:      trials[i].Labels,
+		}); err != nil {
+			return dst, err
+		}
+
+		ta, err := dstAPI.NextTrial(ctx, nextTrialURL)
+		if err != nil {
+			return dst, err
+		}
+		if ta.Location() == "" {
+			return dst, fmt.Errorf("malformed response, missing trial location")
+		}
+
+		if err := dstAPI.ReportTrial(ctx, ta.Location(), trials[i].TrialValues); err != nil {
+			return dst, err
+		}
+	}
+
+	return dst, nil
+}
+
+// checkCompatible verifies the parameters and metrics of the source experiment can be used
+// with the destination experiment.
+func checkCompatible(src, dst *Experiment) error {
+	params := make(map[string]*Parameter, len(dst.Parameters))
+	for i := range dst.Parameters {
+		params[dst.Parameters[i].Name] = &dst.Parameters[i]
+	}
+	for _, p := range src.Parameters {
+		dp, ok := params[p.Name]
+		switch {
+		case !ok:
+			return fmt.Errorf("parameter %q is missing from the destination experiment", p.Name)
+		case dp.Type != p.Type:
+			return fmt.Errorf("parameter %q has type %q in the destination experiment, expected %q", p.Name, dp.Type, p.Type)
+		}
+	}
+
+	metrics := make(map[string]bool, len(dst.Metrics))
+	for _, m := range dst.Metrics {
+		metrics[m.Name] = true
+	}
+	for _, m := range src.Metrics {
+		if !metrics[m.Name] {
+			return fmt.Errorf("metric %q is missing from the destination experiment", m.Name)
+		}
+	}
+
+	return nil
+}
+
+// checkAssignments verifies the assignments are valid for the experiment.
+func checkAssignments(exp *Experiment, assignments []Assignment) error {
+	for i := range assignments {
+		for j := range exp.Parameters {
+			if exp.Parameters[j].Name != assignments[i].ParameterName {
+				continue
+			}
+			if err := CheckParameterValue(&exp.Parameters[j], &assignments[i].Value); err != nil {
+				return fmt.Errorf("parameter %q: %w", assignments[i].ParameterName, err)
+			}
+		}
+	}
+	return CheckParameterConstraints(assignments, exp.Constraints)
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thestormforge/optimize-go/pkg/api"
+)
+
+// memoryAPI is an in-memory experiments API for a single server.
+type memoryAPI struct {
+	API
+	experiments map[ExperimentName]Experiment
+	trials      map[ExperimentName][]TrialItem
+	queue       map[ExperimentName][]TrialAssignments
+}
+
+func newMemoryAPI() *memoryAPI {
+	return &memoryAPI{
+		experiments: make(map[ExperimentName]Experiment),
+		trials:      make(map[ExperimentName][]TrialItem),
+		queue:       make(map[ExperimentName][]TrialAssignments),
+	}
+}
+
+func (m *memoryAPI) GetExperimentByName(_ context.Context, n ExperimentName) (Experiment, error) {
+	exp, ok := m.experiments[n]
+	if !ok {
+		return exp, &api.Error{Type: ErrExperimentNotFound}
+	}
+	exp.Name = n
+	exp.Metadata = api.Metadata{"Link": {
+		"</" + n.String() + "/trials>; rel=https://stormforge.io/rel/trials",
+		"</" + n.String() + "/nextTrial>; rel=https://stormforge.io/rel/next-trial",
+	}}
+	return exp, nil
+}
+
+func (m *memoryAPI) CreateExperimentByName(ctx context.Context, n ExperimentName, exp Experiment) (Experiment, error) {
+	m.experiments[n] = exp
+	return m.GetExperimentByName(ctx, n)
+}
+
+func (m *memoryAPI) GetAllTrials(_ context.Context, u string, _ TrialListQuery) (TrialList, error) {
+	n := ExperimentName(strings.TrimSuffix(strings.TrimPrefix(u, "/"), "/trials"))
+	return TrialList{Trials: append([]TrialItem(nil), m.trials[n]...)}, nil
+}
+
+func (m *memoryAPI) CreateTrial(_ context.Context, u string, ta TrialAssignments) (TrialAssignments, error) {
+	n := ExperimentName(strings.TrimSuffix(strings.TrimPrefix(u, "/"), "/trials"))
+	m.queue[n] = append(m.queue[n], ta)
+	return ta, nil
+}
+
+func (m *memoryAPI) NextTrial(_ context.Context, u string) (TrialAssignments, error) {
+	n := ExperimentName(strings.TrimSuffix(strings.TrimPrefix(u, "/"), "/nextTrial"))
+	if len(m.queue[n]) == 0 {
+		return TrialAssignments{}, &api.Error{Type: ErrTrialUnavailable}
+	}
+
+	ta := m.queue[n][0]
+	m.queue[n] = m.queue[n][1:]
+	num := len(m.trials[n]) + 1
+	m.trials[n] = append(m.trials[n], TrialItem{TrialAssignments: ta, Number: int64(num), Status: TrialActive})
+	ta.Metadata = api.Metadata{"Location": {fmt.Sprintf("/%s/trials/%d", n, num)}}
+	return ta, nil
+}
+
+func (m *memoryAPI) ReportTrial(_ context.Context, u string, vls TrialValues) error {
+	var n ExperimentName
+	var num int
+	if _, err := fmt.Sscanf(strings.Replace(u, "/trials/", " ", 1), "/%s %d", &n, &num); err != nil {
+		return err
+	}
+	m.trials[n][num-1].TrialValues = vls
+	m.trials[n][num-1].Status = TrialCompleted
+	return nil
+}
+
+func TestCloneExperiment(t *testing.T) {
+	src := newMemoryAPI()
+	src.experiments["source"] = Experiment{
+		DisplayName: "Source",
+		Metrics:     []Metric{{Name: "cost", Minimize: true}},
+		Parameters: []Parameter{
+			{Name: "cpu", Type: ParameterTypeInteger, Bounds: &Bounds{Min: "100", Max: "4000"}},
+			{Name: "gc", Type: ParameterTypeCategorical, Values: []string{"G1", "Parallel"}},
+		},
+		Observations: 3,
+	}
+	src.trials["source"] = []TrialItem{
+		{
+			Number:           2,
+			Status:           TrialCompleted,
+			TrialAssignments: TrialAssignments{Assignments: []Assignment{{ParameterName: "cpu", Value: api.FromInt64(500)}, {ParameterName: "gc", Value: api.FromString("Parallel")}}},
+			TrialValues:      TrialValues{Values: []Value{{MetricName: "cost", Value: 5}}},
+		},
+		{
+			Number:           1,
+			Status:           TrialCompleted,
+			TrialAssignments: TrialAssignments{Assignments: []Assignment{{ParameterName: "cpu", Value: api.FromInt64(1000)}, {ParameterName: "gc", Value: api.FromString("G1")}}},
+			TrialValues:      TrialValues{Values: []Value{{MetricName: "cost", Value: 10}}},
+		},
+		{
+			Number:           3,
+			Status:           TrialFailed,
+			TrialAssignments: TrialAssignments{Assignments: []Assignment{{ParameterName: "cpu", Value: api.FromInt64(100)}, {ParameterName: "gc", Value: api.FromString("G1")}}},
+			TrialValues:      TrialValues{Failed: true},
+		},
+	}
+
+	dst := newMemoryAPI()
+	exp, err := CloneExperiment(context.Background(), src, "source", dst, "copy", true)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, ExperimentName("copy"), exp.Name)
+	assert.Equal(t, "Source", dst.experiments["copy"].DisplayName)
+	assert.Equal(t, src.experiments["source"].Parameters, dst.experiments["copy"].Parameters)
+	assert.Equal(t, int64(0), dst.experiments["copy"].Observations)
+
+	// Only completed trials are copied, in order
+	if assert.Len(t, dst.trials["copy"], 2) {
+		for i, expected := range []TrialItem{src.trials["source"][1], src.trials["source"][0]} {
+			actual := dst.trials["copy"][i]
+			assert.Equal(t, TrialCompleted, actual.Status)
+			assert.Equal(t, expected.Assignments, actual.Assignments)
+			assert.Equal(t, expected.Values, actual.Values)
+		}
+	}
+	assert.Empty(t, dst.queue["copy"])
+
+	// The definition alone can be copied
+	_, err = CloneExperiment(context.Background(), src, "source", dst, "definition", false)
+	if assert.NoError(t, err) {
+		assert.Contains(t, dst.experiments, ExperimentName("definition"))
+		assert.Empty(t, dst.trials["definition"])
+	}
+}
+
+func TestCheckCompatible(t *testing.T) {
+	src := &Experiment{
+		Metrics:    []Metric{{Name: "cost"}},
+		Parameters: []Parameter{{Name: "cpu", Type: ParameterTypeInteger}},
+	}
+
+	assert.NoError(t, checkCompatible(src, src))
+	assert.EqualError(t, checkCompatible(src, &Experiment{Metrics: src.Metrics}), `parameter "cpu" is missing from the destination experiment`)
+	assert.EqualError(t, checkCompatible(src, &Experiment{Metrics: src.Metrics, Parameters: []Parameter{{Name: "cpu", Type: ParameterTypeDouble}}}), `parameter "cpu" has type "double" in the destination experiment, expected "int"`)
+	assert.EqualError(t, checkCompatible(src, &Experiment{Parameters: src.Parameters}), `metric "cost" is missing from the destination experiment`)
+}
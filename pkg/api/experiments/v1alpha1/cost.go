@@ -0,0 +1,64 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+// CostMetricName is the name of the metric used to track the cost of a trial.
+const CostMetricName = "cost"
+
+// TrialCost is a summary of the cost of running an experiment.
+type TrialCost struct {
+	// The number of completed trials reporting a cost.
+	Trials int `json:"trials"`
+	// The total cost of the completed trials.
+	Spent float64 `json:"spent"`
+	// The estimated cost of the trials remaining in the budget.
+	Remaining float64 `json:"remaining"`
+	// The estimated total cost of the experiment once the budget is exhausted.
+	Projected float64 `json:"projected"`
+}
+
+// CostSummary computes the cost of the completed trials and projects the cost of the
+// remaining trials using the average cost per trial. The returned boolean is false
+// if none of the completed trials reported a cost metric.
+func CostSummary(trials []TrialItem, budget int) (TrialCost, bool) {
+	c := TrialCost{}
+	for i := range trials {
+		if trials[i].Status != TrialCompleted {
+			continue
+		}
+
+		for _, v := range trials[i].Values {
+			if v.MetricName == CostMetricName {
+				c.Trials++
+				c.Spent += v.Value
+				break
+			}
+		}
+	}
+
+	if c.Trials == 0 {
+		return c, false
+	}
+
+	c.Projected = c.Spent
+	if remaining := budget - c.Trials; remaining > 0 {
+		c.Remaining = c.Spent / float64(c.Trials) * float64(remaining)
+		c.Projected += c.Remaining
+	}
+
+	return c, true
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCostSummary(t *testing.T) {
+	completed := func(values ...Value) TrialItem {
+		return TrialItem{Status: TrialCompleted, TrialValues: TrialValues{Values: values}}
+	}
+
+	cases := []struct {
+		desc     string
+		trials   []TrialItem
+		budget   int
+		expected TrialCost
+		ok       bool
+	}{
+		{
+			desc: "no trials",
+		},
+		{
+			desc: "no cost metric",
+			trials: []TrialItem{
+				completed(Value{MetricName: "duration", Value: 10}),
+			},
+			budget: 10,
+		},
+		{
+			desc: "partial budget",
+			trials: []TrialItem{
+				completed(Value{MetricName: "cost", Value: 10}, Value{MetricName: "duration", Value: 100}),
+				completed(Value{MetricName: "cost", Value: 30}),
+				{Status: TrialFailed},
+				{Status: TrialActive},
+			},
+			budget:   10,
+			expected: TrialCost{Trials: 2, Spent: 40, Remaining: 160, Projected: 200},
+			ok:       true,
+		},
+		{
+			desc: "exhausted budget",
+			trials: []TrialItem{
+				completed(Value{MetricName: "cost", Value: 10}),
+				completed(Value{MetricName: "cost", Value: 20}),
+			},
+			budget:   1,
+			expected: TrialCost{Trials: 2, Spent: 30, Projected: 30},
+			ok:       true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			actual, ok := CostSummary(c.trials, c.budget)
+			assert.Equal(t, c.ok, ok)
+			if c.ok {
+				assert.Equal(t, c.expected, actual)
+			}
+		})
+	}
+}
@@ -18,10 +18,19 @@ package v1alpha1
 
 import (
 	"encoding/json"
+	"fmt"
+	"strconv"
 
 	"github.com/thestormforge/optimize-go/pkg/api"
 )
 
+const (
+	// OptimizationExperimentBudget is the name of the optimization parameter controlling the number of trials.
+	OptimizationExperimentBudget = "experimentBudget"
+	// OptimizationParallelTrials is the name of the optimization parameter controlling the number of concurrent trials.
+	OptimizationParallelTrials = "parallelTrials"
+)
+
 type Optimization struct {
 	// The name of the optimization parameter.
 	Name string `json:"name"`
@@ -128,6 +137,51 @@ type Experiment struct {
 	Labels map[string]string `json:"labels,omitempty"`
 }
 
+// GetOptimization returns the value of the named optimization parameter.
+func (e *Experiment) GetOptimization(name string) (string, bool) {
+	for i := range e.Optimization {
+		if e.Optimization[i].Name == name {
+			return e.Optimization[i].Value, true
+		}
+	}
+	return "", false
+}
+
+// SetOptimization sets the value of the named optimization parameter.
+func (e *Experiment) SetOptimization(name, value string) {
+	for i := range e.Optimization {
+		if e.Optimization[i].Name == name {
+			e.Optimization[i].Value = value
+			return
+		}
+	}
+	e.Optimization = append(e.Optimization, Optimization{Name: name, Value: value})
+}
+
+// ExperimentBudget returns the configured number of trials for the experiment, or zero if it is not set.
+func (e *Experiment) ExperimentBudget() int {
+	v, _ := e.GetOptimization(OptimizationExperimentBudget)
+	n, _ := strconv.Atoi(v)
+	return n
+}
+
+// SetExperimentBudget sets the number of trials for the experiment.
+func (e *Experiment) SetExperimentBudget(budget int) {
+	e.SetOptimization(OptimizationExperimentBudget, strconv.Itoa(budget))
+}
+
+// ParallelTrials returns the configured number of concurrent trials, or zero if it is not set.
+func (e *Experiment) ParallelTrials() int {
+	v, _ := e.GetOptimization(OptimizationParallelTrials)
+	n, _ := strconv.Atoi(v)
+	return n
+}
+
+// SetParallelTrials sets the number of trials that may run concurrently.
+func (e *Experiment) SetParallelTrials(parallelism int) {
+	e.SetOptimization(OptimizationParallelTrials, strconv.Itoa(parallelism))
+}
+
 func (e *Experiment) UnmarshalJSON(data []byte) error {
 	if n := extractExperimentName(e.Metadata); n != "" {
 		e.Name = n
@@ -137,8 +191,40 @@ func (e *Experiment) UnmarshalJSON(data []byte) error {
 	return json.Unmarshal(data, (*t)(e))
 }
 
+// ExperimentPatch is a partial experiment used to update an experiment using JSON
+// merge-patch semantics, only the fields which are set will be modified.
+type ExperimentPatch struct {
+	// The display name of the experiment.
+	DisplayName *string `json:"displayName,omitempty"`
+	// The target number of observations for this experiment.
+	Budget *int64 `json:"budget,omitempty"`
+	// Controls how the optimizer will generate trials.
+	Optimization []Optimization `json:"optimization,omitempty"`
+	// Labels to change on this experiment, a nil value removes the label.
+	Labels map[string]*string `json:"labels,omitempty"`
+}
+
 type ExperimentListQuery struct{ api.IndexQuery }
 
+// SetScenario filters the experiments to those belonging to the specified application
+// and scenario. The scenario is optional, if it is empty all the experiments for the
+// application will be included.
+func (q *ExperimentListQuery) SetScenario(application, scenario string) error {
+	labels := map[string]string{"application": application}
+	if !nameRegexp.MatchString(application) {
+		return fmt.Errorf("invalid label value (must be lowercase): application=%q", application)
+	}
+	if scenario != "" {
+		if !nameRegexp.MatchString(scenario) {
+			return fmt.Errorf("invalid label value (must be lowercase): scenario=%q", scenario)
+		}
+		labels["scenario"] = scenario
+	}
+
+	q.SetLabelSelector(labels)
+	return nil
+}
+
 type ExperimentItem struct {
 	Experiment
 }
@@ -151,6 +237,8 @@ func (ei *ExperimentItem) UnmarshalJSON(b []byte) error {
 type ExperimentList struct {
 	// The experiment list metadata.
 	api.Metadata `json:"-"`
+	// The total number of items in the collection.
+	TotalCount int `json:"totalCount,omitempty"`
 	// The list of experiments.
 	Experiments []ExperimentItem `json:"experiments,omitempty"`
 }
@@ -17,8 +17,14 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -76,3 +82,241 @@ func TestExperimentList_UnmarshalJSON(t *testing.T) {
 		assert.Equal(t, "", l.Experiments[1].Title())
 	}
 }
+
+func TestExperiment_Optimization(t *testing.T) {
+	exp := Experiment{}
+	exp.SetExperimentBudget(20)
+	exp.SetParallelTrials(2)
+	exp.SetExperimentBudget(40)
+
+	assert.Equal(t, []Optimization{
+		{Name: OptimizationExperimentBudget, Value: "40"},
+		{Name: OptimizationParallelTrials, Value: "2"},
+	}, exp.Optimization)
+
+	data, err := json.Marshal(&exp)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.JSONEq(t, `{"optimization":[{"name":"experimentBudget","value":"40"},{"name":"parallelTrials","value":"2"}],"metrics":null,"parameters":null}`, string(data))
+
+	actual := Experiment{}
+	if assert.NoError(t, json.Unmarshal(data, &actual)) {
+		assert.Equal(t, 40, actual.ExperimentBudget())
+		assert.Equal(t, 2, actual.ParallelTrials())
+	}
+}
+
+func TestHTTPAPI_CreateExperiment_Optimization(t *testing.T) {
+	var sent Experiment
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&sent))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"optimization":[{"name":"experimentBudget","value":"10"},{"name":"parallelTrials","value":"3"}]}`))
+	}))
+	defer srv.Close()
+
+	client, err := api.NewClient(srv.URL, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	exp := Experiment{}
+	exp.SetExperimentBudget(10)
+	exp.SetParallelTrials(3)
+
+	created, err := NewAPI(client).CreateExperimentByName(context.Background(), "test", exp)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 10, sent.ExperimentBudget())
+		assert.Equal(t, 3, sent.ParallelTrials())
+		assert.Equal(t, 10, created.ExperimentBudget())
+		assert.Equal(t, 3, created.ParallelTrials())
+	}
+}
+
+// fakeCodec is an alternate content type which wraps JSON with a prefix.
+type fakeCodec struct{}
+
+func (fakeCodec) ContentType() string { return "application/x-fake" }
+
+func (fakeCodec) Unmarshal(data []byte, v interface{}) error {
+	if !bytes.HasPrefix(data, []byte("fake:")) {
+		return errors.New("not fake")
+	}
+	return json.Unmarshal(data[5:], v)
+}
+
+func TestHTTPAPI_CreateExperiment_Codec(t *testing.T) {
+	var contentTypes []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		exp := Experiment{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&exp))
+
+		b, err := json.Marshal(exp)
+		assert.NoError(t, err)
+		if strings.Contains(r.Header.Get("Accept"), "application/x-fake") {
+			b = append([]byte("fake:"), b...)
+			w.Header().Set("Content-Type", "application/x-fake; charset=binary")
+		} else {
+			w.Header().Set("Content-Type", "application/json")
+		}
+		contentTypes = append(contentTypes, w.Header().Get("Content-Type"))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write(b)
+	}))
+	defer srv.Close()
+
+	exp := Experiment{
+		DisplayName: "Test",
+		Parameters: []Parameter{
+			{Name: "cpu", Type: ParameterTypeInteger, Bounds: &Bounds{Min: "100", Max: "4000"}},
+			{Name: "gc", Type: ParameterTypeCategorical, Values: []string{"G1", "Parallel"}},
+		},
+		Metrics: []Metric{{Name: "cost", Minimize: true}},
+	}
+
+	for _, opts := range [][]api.ClientOption{nil, {api.WithCodec(fakeCodec{})}} {
+		client, err := api.NewClient(srv.URL, nil, opts...)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		created, err := NewAPI(client).CreateExperimentByName(context.Background(), "test", exp)
+		if assert.NoError(t, err) {
+			assert.Equal(t, exp.DisplayName, created.DisplayName)
+			assert.Equal(t, exp.Parameters, created.Parameters)
+			assert.Equal(t, exp.Metrics, created.Metrics)
+		}
+	}
+
+	assert.Equal(t, []string{"application/json", "application/x-fake; charset=binary"}, contentTypes)
+}
+
+func TestHTTPAPI_GetExperimentIfModified(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"displayName":"Test"}`))
+	}))
+	defer srv.Close()
+
+	client, err := api.NewClient(srv.URL, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	expAPI := NewAPI(client)
+	u := client.URL("/v1/experiments/test").String()
+
+	exp, err := expAPI.GetExperiment(context.Background(), u)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Test", exp.DisplayName)
+		assert.Equal(t, `"v1"`, exp.ETag())
+	}
+
+	_, err = expAPI.GetExperimentIfModified(context.Background(), u, exp.ETag())
+	var apiErr *api.Error
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, ErrExperimentNotModified, apiErr.Type)
+	}
+}
+
+func TestHTTPAPI_PatchExperiment(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPatch, r.Method)
+		assert.Equal(t, "application/merge-patch+json", r.Header.Get("Content-Type"))
+		body, err := io.ReadAll(r.Body)
+		if assert.NoError(t, err) {
+			assert.JSONEq(t, `{"budget":50,"labels":{"team":"a","old":null}}`, string(body))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"budget":50,"labels":{"team":"a"}}`))
+	}))
+	defer srv.Close()
+
+	client, err := api.NewClient(srv.URL, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	budget := int64(50)
+	team := "a"
+	exp, err := NewAPI(client).PatchExperiment(context.Background(), client.URL("/v1/experiments/test").String(), ExperimentPatch{
+		Budget: &budget,
+		Labels: map[string]*string{"team": &team, "old": nil},
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(50), exp.Budget)
+		assert.Equal(t, map[string]string{"team": "a"}, exp.Labels)
+	}
+}
+
+func TestHTTPAPI_GetAllExperiments_Pagination(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Link", "</v1/experiments/?offset=0&limit=2>; rel=prev, </v1/experiments/?offset=4&limit=2>; rel=next")
+		_, _ = w.Write([]byte(`{"totalCount": 7, "experiments": [{"displayName": "three"}, {"displayName": "four"}]}`))
+	}))
+	defer srv.Close()
+
+	client, err := api.NewClient(srv.URL, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	lst, err := NewAPI(client).GetAllExperiments(context.Background(), ExperimentListQuery{})
+	if assert.NoError(t, err) {
+		assert.Equal(t, 7, lst.TotalCount)
+		assert.Equal(t, srv.URL+"/v1/experiments/?offset=4&limit=2", lst.NextPageURL())
+		assert.Equal(t, srv.URL+"/v1/experiments/?offset=0&limit=2", lst.PrevPageURL())
+		assert.Len(t, lst.Experiments, 2)
+	}
+}
+
+func TestHTTPAPI_StrictDecoding(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/experiments/known":
+			_, _ = w.Write([]byte(`{"_metadata": {"Title": "Known"}, "displayName": "known", "metrics": [{"name": "cost", "minimize": true}]}`))
+		case "/v1/experiments/unknown":
+			_, _ = w.Write([]byte(`{"displayName": "unknown", "addedLater": true}`))
+		case "/v1/experiments/nested":
+			_, _ = w.Write([]byte(`{"displayName": "nested", "metrics": [{"name": "cost", "addedLater": true}]}`))
+		case "/v1/experiments/":
+			_, _ = w.Write([]byte(`{"totalCount": 1, "experiments": [{"displayName": "item", "observations": 1, "addedLater": true}]}`))
+		case "/v1/experiments/known/trials/":
+			_, _ = w.Write([]byte(`{"trials": [{"number": 1, "status": "completed", "values": [{"metricName": "cost", "value": 1, "addedLater": 1}]}]}`))
+		}
+	}))
+	defer srv.Close()
+
+	client, err := api.NewClient(srv.URL, nil, api.WithStrictDecoding())
+	if !assert.NoError(t, err) {
+		return
+	}
+	expAPI := NewAPI(client)
+	ctx := context.Background()
+
+	exp, err := expAPI.GetExperiment(ctx, srv.URL+"/v1/experiments/known")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "known", exp.DisplayName)
+	}
+
+	_, err = expAPI.GetExperiment(ctx, srv.URL+"/v1/experiments/unknown")
+	assert.ErrorContains(t, err, "addedLater")
+
+	_, err = expAPI.GetExperiment(ctx, srv.URL+"/v1/experiments/nested")
+	assert.ErrorContains(t, err, "addedLater")
+
+	_, err = expAPI.GetAllExperiments(ctx, ExperimentListQuery{})
+	assert.ErrorContains(t, err, "addedLater")
+
+	_, err = expAPI.GetAllTrials(ctx, srv.URL+"/v1/experiments/known/trials/", TrialListQuery{})
+	assert.ErrorContains(t, err, "addedLater")
+}
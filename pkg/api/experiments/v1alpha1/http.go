@@ -105,7 +105,7 @@ func (h *httpAPI) GetAllExperimentsByPage(ctx context.Context, u string) (Experi
 	switch resp.StatusCode {
 	case http.StatusOK:
 		api.UnmarshalMetadata(resp, &lst.Metadata)
-		err = json.Unmarshal(body, &lst)
+		err = api.UnmarshalResponse(h.client, resp, body, &lst)
 		return lst, err
 	default:
 		return lst, api.NewUnexpectedError(resp, body)
@@ -126,12 +126,19 @@ func (h *httpAPI) GetExperimentByName(ctx context.Context, n ExperimentName) (Ex
 }
 
 func (h *httpAPI) GetExperiment(ctx context.Context, u string) (Experiment, error) {
+	return h.GetExperimentIfModified(ctx, u, "")
+}
+
+func (h *httpAPI) GetExperimentIfModified(ctx context.Context, u string, etag string) (Experiment, error) {
 	e := Experiment{}
 
 	req, err := http.NewRequest(http.MethodGet, u, nil)
 	if err != nil {
 		return e, err
 	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
 
 	resp, body, err := h.client.Do(ctx, req)
 	if err != nil {
@@ -141,8 +148,10 @@ func (h *httpAPI) GetExperiment(ctx context.Context, u string) (Experiment, erro
 	switch resp.StatusCode {
 	case http.StatusOK:
 		api.UnmarshalMetadata(resp, &e.Metadata)
-		err = json.Unmarshal(body, &e)
+		err = api.UnmarshalResponse(h.client, resp, body, &e)
 		return e, err
+	case http.StatusNotModified:
+		return e, api.NewError(ErrExperimentNotModified, resp, body)
 	case http.StatusNotFound:
 		return e, api.NewError(ErrExperimentNotFound, resp, body)
 	default:
@@ -172,7 +181,7 @@ func (h *httpAPI) CreateExperiment(ctx context.Context, u string, exp Experiment
 	switch resp.StatusCode {
 	case http.StatusOK, http.StatusCreated:
 		api.UnmarshalMetadata(resp, &e.Metadata)
-		err = json.Unmarshal(body, &e)
+		err = api.UnmarshalResponse(h.client, resp, body, &e)
 		return e, err
 	case http.StatusBadRequest:
 		return e, api.NewError(ErrExperimentNameInvalid, resp, body)
@@ -185,6 +194,37 @@ func (h *httpAPI) CreateExperiment(ctx context.Context, u string, exp Experiment
 	}
 }
 
+func (h *httpAPI) PatchExperiment(ctx context.Context, u string, patch ExperimentPatch) (Experiment, error) {
+	e := Experiment{}
+
+	req, err := httpNewJSONRequest(http.MethodPatch, u, patch)
+	if err != nil {
+		return e, err
+	}
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+
+	resp, body, err := h.client.Do(ctx, req)
+	if err != nil {
+		return e, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		api.UnmarshalMetadata(resp, &e.Metadata)
+		err = api.UnmarshalResponse(h.client, resp, body, &e)
+		return e, err
+	case http.StatusNoContent:
+		api.UnmarshalMetadata(resp, &e.Metadata)
+		return e, nil
+	case http.StatusNotFound:
+		return e, api.NewError(ErrExperimentNotFound, resp, body)
+	case http.StatusUnprocessableEntity:
+		return e, api.NewError(ErrExperimentInvalid, resp, body)
+	default:
+		return e, api.NewUnexpectedError(resp, body)
+	}
+}
+
 func (h *httpAPI) DeleteExperiment(ctx context.Context, u string) error {
 	req, err := http.NewRequest(http.MethodDelete, u, nil)
 	if err != nil {
@@ -226,7 +266,8 @@ func (h *httpAPI) GetAllTrials(ctx context.Context, u string, q TrialListQuery)
 
 	switch resp.StatusCode {
 	case http.StatusOK:
-		err = json.Unmarshal(body, &lst)
+		err = api.UnmarshalResponse(h.client, resp, body, &lst)
+		api.UnmarshalMetadata(resp, &lst.Metadata)
 		return lst, err
 	default:
 		return lst, api.NewUnexpectedError(resp, body)
@@ -249,7 +290,7 @@ func (h *httpAPI) CreateTrial(ctx context.Context, u string, asm TrialAssignment
 	switch resp.StatusCode {
 	case http.StatusCreated, http.StatusAccepted:
 		api.UnmarshalMetadata(resp, &ta.Metadata)
-		err = json.Unmarshal(body, &ta)
+		err = api.UnmarshalResponse(h.client, resp, body, &ta)
 		return ta, err
 	case http.StatusConflict:
 		return ta, api.NewError(ErrExperimentStopped, resp, body)
@@ -276,7 +317,7 @@ func (h *httpAPI) NextTrial(ctx context.Context, u string) (TrialAssignments, er
 	switch resp.StatusCode {
 	case http.StatusOK:
 		api.UnmarshalMetadata(resp, &asm.Metadata)
-		err = json.Unmarshal(body, &asm)
+		err = api.UnmarshalResponse(h.client, resp, body, &asm)
 		return asm, err
 	case http.StatusGone:
 		return asm, api.NewError(ErrExperimentStopped, resp, body)
@@ -287,6 +328,42 @@ func (h *httpAPI) NextTrial(ctx context.Context, u string) (TrialAssignments, er
 	}
 }
 
+// PeekNextTrial returns the assignments the optimizer would suggest for the next trial without
+// making the trial active.
+//
+// EXPERIMENTAL: the only documented verb for the "next trial" link is POST, previewing is a
+// speculative GET of the same link which servers do not advertise support for. Servers which
+// reject the GET with a 405 (Method Not Allowed) or 501 (Not Implemented) produce an
+// `ErrNotSupported` error; other servers may respond in ways the client cannot interpret.
+func (h *httpAPI) PeekNextTrial(ctx context.Context, u string) (TrialAssignments, error) {
+	asm := TrialAssignments{}
+
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return asm, err
+	}
+
+	resp, body, err := h.client.Do(ctx, req)
+	if err != nil {
+		return asm, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		api.UnmarshalMetadata(resp, &asm.Metadata)
+		err = api.UnmarshalResponse(h.client, resp, body, &asm)
+		return asm, err
+	case http.StatusGone:
+		return asm, api.NewError(ErrExperimentStopped, resp, body)
+	case http.StatusServiceUnavailable:
+		return asm, api.NewError(ErrTrialUnavailable, resp, body)
+	case http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return asm, api.NewError(ErrNotSupported, resp, body)
+	default:
+		return asm, api.NewUnexpectedError(resp, body)
+	}
+}
+
 func (h *httpAPI) ReportTrial(ctx context.Context, u string, vls TrialValues) error {
 	if vls.Failed {
 		vls.Values = nil
@@ -324,6 +401,106 @@ func (h *httpAPI) ReportTrial(ctx context.Context, u string, vls TrialValues) er
 	}
 }
 
+// UpdateTrialValues merges metric values into a trial that has not been reported yet. Values
+// for metrics previously supplied are replaced. The trial must still be finalized.
+func (h *httpAPI) UpdateTrialValues(ctx context.Context, u string, values []Value) error {
+	// A merge-patch replaces arrays entirely, so the existing values must be included
+	current, err := h.getTrial(ctx, u)
+	if err != nil {
+		return err
+	}
+
+	req, err := httpNewJSONRequest(http.MethodPatch, u, TrialValues{Values: mergeValues(current.Values, values)})
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	if etag := current.ETag(); etag != "" {
+		req.Header.Set("If-Match", etag)
+	}
+
+	resp, body, err := h.client.Do(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+		return nil
+	case http.StatusNotFound:
+		return api.NewError(ErrTrialNotFound, resp, body)
+	case http.StatusConflict:
+		return api.NewError(ErrTrialAlreadyReported, resp, body)
+	case http.StatusUnprocessableEntity:
+		return api.NewError(ErrTrialInvalid, resp, body)
+	default:
+		return api.NewUnexpectedError(resp, body)
+	}
+}
+
+// FinalizeTrial reports a trial which may have received partial values. Only the values not
+// previously supplied through `UpdateTrialValues` need to be included, values for metrics
+// previously supplied are replaced.
+func (h *httpAPI) FinalizeTrial(ctx context.Context, u string, vls TrialValues) error {
+	if !vls.Failed {
+		current, err := h.getTrial(ctx, u)
+		if err != nil {
+			return err
+		}
+		vls.Values = mergeValues(current.Values, vls.Values)
+	}
+
+	return h.ReportTrial(ctx, u, vls)
+}
+
+// getTrial retrieves the current state of an individual trial.
+func (h *httpAPI) getTrial(ctx context.Context, u string) (TrialItem, error) {
+	result := TrialItem{}
+
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return result, err
+	}
+
+	resp, body, err := h.client.Do(ctx, req)
+	if err != nil {
+		return result, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		// Decode the body first, the embedded metadata is reset during decoding
+		if err := api.UnmarshalResponse(h.client, resp, body, &result); err != nil {
+			return result, err
+		}
+		api.UnmarshalMetadata(resp, &result.Metadata)
+		return result, nil
+	case http.StatusNotFound:
+		return result, api.NewError(ErrTrialNotFound, resp, body)
+	default:
+		return result, api.NewUnexpectedError(resp, body)
+	}
+}
+
+// mergeValues returns the current values updated with the supplied values, keyed by metric name.
+func mergeValues(current, values []Value) []Value {
+	result := make([]Value, 0, len(current)+len(values))
+	result = append(result, current...)
+	for _, v := range values {
+		replaced := false
+		for i := range result {
+			if result[i].MetricName == v.MetricName {
+				result[i], replaced = v, true
+				break
+			}
+		}
+		if !replaced {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
 func (h *httpAPI) AbandonRunningTrial(ctx context.Context, u string) error {
 	req, err := http.NewRequest(http.MethodDelete, u, nil)
 	if err != nil {
@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"sort"
+	"sync"
 
 	"github.com/thestormforge/optimize-go/pkg/api"
 )
@@ -31,20 +32,106 @@ type Lister struct {
 	API API
 	// BatchSize overrides the default batch size for fetching lists.
 	BatchSize int
+	// MaxItems stops iteration after the specified number of items have been visited.
+	MaxItems int
+	// Concurrency is the maximum number of experiments to fetch trials for in parallel.
+	Concurrency int
+	// Progress is invoked after each item is visited with the number of items visited
+	// so far and the total number of items (zero if the total is not known).
+	Progress func(visited, total int)
+
+	// Experiments fetched by name are cached for the lifetime of the lister.
+	mu          sync.Mutex
+	experiments map[ExperimentName]Experiment
+}
+
+// getExperimentByName returns the named experiment, only fetching it on first use.
+func (l *Lister) getExperimentByName(ctx context.Context, name ExperimentName) (Experiment, error) {
+	l.mu.Lock()
+	exp, ok := l.experiments[name]
+	l.mu.Unlock()
+	if ok {
+		return exp, nil
+	}
+
+	exp, err := l.API.GetExperimentByName(ctx, name)
+	if err != nil {
+		return exp, err
+	}
+
+	l.mu.Lock()
+	if l.experiments == nil {
+		l.experiments = make(map[ExperimentName]Experiment)
+	}
+	l.experiments[name] = exp
+	l.mu.Unlock()
+	return exp, nil
+}
+
+// errMaxItems is used internally to stop iteration once the maximum number of items is reached.
+var errMaxItems = errors.New("maximum number of items reached")
+
+// limit tracks the number of visited items so iteration can stop at a maximum.
+type limit struct {
+	max, count, total int
+	progress          func(int, int)
+}
+
+// newLimit returns a new limit for a single iteration.
+func (l *Lister) newLimit() *limit {
+	return &limit{max: l.MaxItems, progress: l.Progress}
+}
+
+// setTotal records the total number of items, if it is known.
+func (lim *limit) setTotal(total int) {
+	if total <= 0 {
+		return
+	}
+	if lim.max > 0 && total > lim.max {
+		total = lim.max
+	}
+	lim.total = total
+}
+
+// visited records a visited item, returning `errMaxItems` once the limit is reached.
+func (lim *limit) visited() error {
+	lim.count++
+	if lim.progress != nil {
+		lim.progress(lim.count, lim.total)
+	}
+	if lim.max > 0 && lim.count >= lim.max {
+		return errMaxItems
+	}
+	return nil
+}
+
+// done translates the internal error used to stop iteration.
+func (lim *limit) done(err error) error {
+	if err == errMaxItems {
+		return nil
+	}
+	return err
 }
 
 // ForEachExperiment iterates over all the experiments matching the supplied query.
 func (l *Lister) ForEachExperiment(ctx context.Context, q ExperimentListQuery, f func(*ExperimentItem) error) error {
+	lim := l.newLimit()
+
 	// Define a helper to iteratively (NOT recursively) visit experiments
 	forEach := func(lst ExperimentList, err error) (string, error) {
 		if err != nil {
 			return "", err
 		}
 
+		lim.setTotal(lst.TotalCount)
+
 		for i := range lst.Experiments {
 			if err := f(&lst.Experiments[i]); err != nil {
 				return "", err
 			}
+			if err := lim.visited(); err != nil {
+				return "", err
+			}
 			if err := ctx.Err(); err != nil {
 				return "", err
 			}
@@ -63,13 +150,34 @@ func (l *Lister) ForEachExperiment(ctx context.Context, q ExperimentListQuery, f
 	for u != "" && err == nil {
 		u, err = forEach(l.API.GetAllExperimentsByPage(ctx, u))
 	}
-	return err
+	return lim.done(err)
+}
+
+// ListExperimentsForScenario returns all the experiments labeled for the specified application and scenario.
+func (l *Lister) ListExperimentsForScenario(ctx context.Context, application, scenario string) ([]ExperimentItem, error) {
+	q := ExperimentListQuery{}
+	if err := q.SetScenario(application, scenario); err != nil {
+		return nil, err
+	}
+
+	var result []ExperimentItem
+	err := l.ForEachExperiment(ctx, q, func(item *ExperimentItem) error {
+		// Double check the labels in case the server ignored the selector
+		if item.Labels["application"] != application || (scenario != "" && item.Labels["scenario"] != scenario) {
+			return nil
+		}
+		result = append(result, *item)
+		return nil
+	})
+	return result, err
 }
 
 // ForEachNamedExperiment iterates over all the named experiments, optionally ignoring those that do not exist.
 func (l *Lister) ForEachNamedExperiment(ctx context.Context, names []string, ignoreNotFound bool, f func(*ExperimentItem) error) error {
+	lim := l.newLimit()
+	lim.setTotal(len(names))
 	for _, name := range names {
-		exp, err := l.API.GetExperimentByName(ctx, ExperimentName(name))
+		exp, err := l.getExperimentByName(ctx, ExperimentName(name))
 		if err != nil {
 			var notFoundErr *api.Error
 			if errors.As(err, &notFoundErr) && notFoundErr.Type == ErrExperimentNotFound && ignoreNotFound {
@@ -81,12 +189,23 @@ func (l *Lister) ForEachNamedExperiment(ctx context.Context, names []string, ign
 		if err := f(&ExperimentItem{Experiment: exp}); err != nil {
 			return err
 		}
+		if err := lim.visited(); err != nil {
+			return lim.done(err)
+		}
 	}
 	return nil
 }
 
 // ForEachTrial iterates over all trials for an experiment matching the supplied query.
-func (l *Lister) ForEachTrial(ctx context.Context, exp *Experiment, q TrialListQuery, f func(*TrialItem) error) (err error) {
+func (l *Lister) ForEachTrial(ctx context.Context, exp *Experiment, q TrialListQuery, f func(*TrialItem) error) error {
+	lim := l.newLimit()
+	return lim.done(l.forEachTrial(ctx, exp, q, lim, f))
+}
+
+// forEachTrial iterates over all trials for an experiment, the limit is optional.
+func (l *Lister) forEachTrial(ctx context.Context, exp *Experiment, q TrialListQuery, lim *limit, f func(*TrialItem) error) (err error) {
+	// Double check the time range in case the server ignored it, the query is reset after the first page
+	tr := q
 	// Define a helper to iteratively (NOT recursively) list and visit scenarios
 	forEach := func(u string) (string, error) {
 		lst, err := l.API.GetAllTrials(ctx, u, q)
@@ -94,11 +213,23 @@ func (l *Lister) ForEachTrial(ctx context.Context, exp *Experiment, q TrialListQ
 			return "", err
 		}
 
+		if lim != nil {
+			lim.setTotal(lst.TotalCount)
+		}
+
 		for i := range lst.Trials {
+			if !tr.MatchesTimeRange(&lst.Trials[i]) {
+				continue
+			}
 			lst.Trials[i].Experiment = exp
 			if err := f(&lst.Trials[i]); err != nil {
 				return "", err
 			}
+			if lim != nil {
+				if err := lim.visited(); err != nil {
+					return "", err
+				}
+			}
 			if err := ctx.Err(); err != nil {
 				return "", err
 			}
@@ -123,53 +254,140 @@ func (l *Lister) ForEachTrial(ctx context.Context, exp *Experiment, q TrialListQ
 	return
 }
 
+// GetBaseline returns the trial labeled as the baseline for the experiment at the supplied URL.
+// If the experiment does not have a baseline trial, an `ErrTrialNotFound` error is returned.
+func (l *Lister) GetBaseline(ctx context.Context, u string) (*TrialItem, error) {
+	exp, err := l.API.GetExperiment(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+
+	q := TrialListQuery{IndexQuery: api.IndexQuery{}}
+	q.SetLabelSelector(map[string]string{LabelBaseline: "true"})
+
+	var baseline *TrialItem
+	err = l.ForEachTrial(ctx, &exp, q, func(item *TrialItem) error {
+		// Double check the labels in case the server ignored the selector
+		if baseline == nil && item.Labels[LabelBaseline] == "true" {
+			baseline = item
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if baseline == nil {
+		return nil, &api.Error{Type: ErrTrialNotFound, Message: "baseline trial not found", Location: u}
+	}
+	return baseline, nil
+}
+
 // ForEachNamedTrial iterates over all the named trials, optionally ignoring those that do not exist.
+// Trials are visited in the order they are named, however the trials for up to `Concurrency`
+// distinct experiments may be fetched in parallel.
 func (l *Lister) ForEachNamedTrial(ctx context.Context, names []string, q TrialListQuery, ignoreNotFound bool, f func(*TrialItem) error) error {
 	// Overwrite the limit
 	if l.BatchSize > 0 {
 		q.SetLimit(l.BatchSize)
 	}
 
-	cache := make(map[ExperimentName]map[int64]*TrialItem)
+	// Only count the trials visited, not the trials loaded into the cache
+	lim := l.newLimit()
+	visit := func(item *TrialItem) error {
+		if err := f(item); err != nil {
+			return err
+		}
+		return lim.visited()
+	}
+
+	// Stop any outstanding fetches if we return early
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// There is no reliable way to get the per-trial addresses, just load all
+	// the trials for each distinct experiment into memory
+	concurrency := l.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, concurrency)
+	cache := make(map[ExperimentName]*trialCache)
+
+	// Experiments which are only named in full never need more trials than the limit,
+	// individually named trials require all the trials to be loaded
+	limited := make(map[ExperimentName]bool)
 	for _, n := range names {
 		expName, trialNum := SplitTrialName(n)
+		if prev, ok := limited[expName]; ok && !prev {
+			continue
+		}
+		limited[expName] = trialNum < 0 && l.MaxItems > 0
+	}
+
+	for _, n := range names {
+		expName, _ := SplitTrialName(n)
+		if _, ok := cache[expName]; ok {
+			continue
+		}
+
+		var max int
+		if limited[expName] {
+			max = l.MaxItems
+		}
+
+		// Each fetch gets its own copy of the query since the underlying map is mutable
+		eq := TrialListQuery{IndexQuery: make(api.IndexQuery, len(q.IndexQuery))}
+		for k, v := range q.IndexQuery {
+			eq.IndexQuery[k] = append([]string(nil), v...)
+		}
 
-		// There is no reliable way to get the per-trial addresses, just load
-		// all the trials into memory the first time we see the experiment
-		if _, ok := cache[expName]; !ok {
-			exp, err := l.API.GetExperimentByName(ctx, expName)
-			if err != nil {
-				return err
+		tc := &trialCache{done: make(chan struct{})}
+		cache[expName] = tc
+		go func() {
+			defer close(tc.done)
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				tc.err = ctx.Err()
+				return
 			}
+			tc.load(ctx, l, expName, eq, max)
+		}()
+	}
 
-			cache[expName] = make(map[int64]*TrialItem)
-			if err := l.ForEachTrial(ctx, &exp, q, func(item *TrialItem) error {
-				cache[expName][item.Number] = item
-				return nil
-			}); err != nil {
-				return err
+	for _, n := range names {
+		expName, trialNum := SplitTrialName(n)
+		tc := cache[expName]
+		<-tc.done
+		if tc.err != nil {
+			var notFoundErr *api.Error
+			if errors.As(tc.err, &notFoundErr) && notFoundErr.Type == ErrExperimentNotFound && ignoreNotFound {
+				continue
 			}
+			return tc.err
 		}
 
 		// If there was no trial number, emit all trials in descending order
 		if trialNum < 0 {
-			result := make([]*TrialItem, 0, len(cache[expName]))
-			for _, t := range cache[expName] {
+			result := make([]*TrialItem, 0, len(tc.trials))
+			for _, t := range tc.trials {
 				result = append(result, t)
 			}
 			sort.Slice(result, func(i, j int) bool { return result[i].Number > result[j].Number })
 			for _, r := range result {
-				if err := f(r); err != nil {
-					return err
+				if err := visit(r); err != nil {
+					return lim.done(err)
 				}
 			}
-			return nil
+			continue
 		}
 
 		// Get the trial out of the trial cache
-		if t, ok := cache[expName][trialNum]; ok {
-			if err := f(t); err != nil {
-				return err
+		if t, ok := tc.trials[trialNum]; ok {
+			if err := visit(t); err != nil {
+				return lim.done(err)
 			}
 		} else if !ignoreNotFound {
 			return &api.Error{Type: ErrTrialNotFound, Message: fmt.Sprintf("trial not found: %q", n)}
@@ -177,3 +395,27 @@ func (l *Lister) ForEachNamedTrial(ctx context.Context, names []string, q TrialL
 	}
 	return nil
 }
+
+// trialCache holds all the trials for a single experiment.
+type trialCache struct {
+	trials map[int64]*TrialItem
+	err    error
+	done   chan struct{}
+}
+
+// load fetches the trials for the named experiment, stopping after the maximum number of
+// trials (if it is greater than zero).
+func (tc *trialCache) load(ctx context.Context, l *Lister, expName ExperimentName, q TrialListQuery, max int) {
+	exp, err := l.getExperimentByName(ctx, expName)
+	if err != nil {
+		tc.err = err
+		return
+	}
+
+	lim := &limit{max: max}
+	tc.trials = make(map[int64]*TrialItem)
+	tc.err = lim.done(l.forEachTrial(ctx, &exp, q, lim, func(item *TrialItem) error {
+		tc.trials[item.Number] = item
+		return nil
+	}))
+}
@@ -0,0 +1,454 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thestormforge/optimize-go/pkg/api"
+)
+
+func TestExperimentListQuery_SetScenario(t *testing.T) {
+	q := ExperimentListQuery{}
+	if assert.NoError(t, q.SetScenario("my-app", "testing")) {
+		assert.Equal(t, "application=my-app,scenario=testing", url.Values(q.IndexQuery).Get(api.ParamLabelSelector))
+	}
+
+	q = ExperimentListQuery{}
+	if assert.NoError(t, q.SetScenario("my-app", "")) {
+		assert.Equal(t, "application=my-app", url.Values(q.IndexQuery).Get(api.ParamLabelSelector))
+	}
+
+	assert.Error(t, (&ExperimentListQuery{}).SetScenario("My App", ""))
+	assert.Error(t, (&ExperimentListQuery{}).SetScenario("my-app", "Testing"))
+}
+
+func TestLister_ListExperimentsForScenario(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application=my-app,scenario=testing", r.URL.Query().Get(api.ParamLabelSelector))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"experiments": [
+  {"_metadata": {"Link": "</v1/experiments/one>; rel=self"}, "labels": {"application": "my-app", "scenario": "testing"}},
+  {"_metadata": {"Link": "</v1/experiments/two>; rel=self"}, "labels": {"application": "my-app", "scenario": "other"}}
+]}`)
+	}))
+	defer srv.Close()
+
+	client, err := api.NewClient(srv.URL, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	l := Lister{API: NewAPI(client)}
+	items, err := l.ListExperimentsForScenario(context.Background(), "my-app", "testing")
+	if assert.NoError(t, err) && assert.Len(t, items, 1) {
+		assert.Equal(t, "one", items[0].Name.String())
+	}
+}
+
+func TestLister_GetBaseline(t *testing.T) {
+	var srv *httptest.Server
+	var trials string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/experiments/test", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Link", fmt.Sprintf("<%s/v1/experiments/test/trials>; rel=https://stormforge.io/rel/trials", srv.URL))
+		_, _ = fmt.Fprint(w, `{}`)
+	})
+	mux.HandleFunc("/v1/experiments/test/trials", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "baseline=true", r.URL.Query().Get(api.ParamLabelSelector))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, trials)
+	})
+	srv = httptest.NewServer(mux)
+	defer srv.Close()
+
+	client, err := api.NewClient(srv.URL, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	l := Lister{API: NewAPI(client)}
+	u := client.URL("/v1/experiments/test").String()
+
+	// The selector is ignored by the server, make sure we still find the baseline
+	trials = `{"trials": [
+  {"number": 1, "status": "completed", "labels": {"best": "true"}},
+  {"number": 2, "status": "completed", "labels": {"baseline": "true"}},
+  {"number": 3, "status": "completed"}
+]}`
+	baseline, err := l.GetBaseline(context.Background(), u)
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(2), baseline.Number)
+	}
+
+	trials = `{"trials": [{"number": 1, "status": "completed"}]}`
+	_, err = l.GetBaseline(context.Background(), u)
+	var apiErr *api.Error
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, ErrTrialNotFound, apiErr.Type)
+	}
+}
+
+func TestLister_MaxItems(t *testing.T) {
+	var pages []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		offset := r.URL.Query().Get("offset")
+		pages = append(pages, offset)
+		next := map[string]string{"": "2", "2": "4", "4": ""}[offset]
+		if next != "" {
+			w.Header().Set("Link", fmt.Sprintf("</v1/experiments/?offset=%s>; rel=next", next))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"experiments": [{"displayName": "a"}, {"displayName": "b"}]}`)
+	}))
+	defer srv.Close()
+
+	client, err := api.NewClient(srv.URL, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	cases := []struct {
+		desc     string
+		maxItems int
+		visited  int
+		pages    []string
+	}{
+		{
+			desc:    "unlimited",
+			visited: 6,
+			pages:   []string{"", "2", "4"},
+		},
+		{
+			desc:     "across pages",
+			maxItems: 3,
+			visited:  3,
+			pages:    []string{"", "2"},
+		},
+		{
+			desc:     "page boundary",
+			maxItems: 2,
+			visited:  2,
+			pages:    []string{""},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			pages = nil
+			l := Lister{API: NewAPI(client), MaxItems: c.maxItems}
+			var visited int
+			err := l.ForEachExperiment(context.Background(), ExperimentListQuery{}, func(*ExperimentItem) error {
+				visited++
+				return nil
+			})
+			if assert.NoError(t, err) {
+				assert.Equal(t, c.visited, visited)
+				assert.Equal(t, c.pages, pages)
+			}
+		})
+	}
+}
+
+func TestLister_ForEachNamedTrial_MaxItems(t *testing.T) {
+	var pages []string
+	var srv *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/experiments/fixture", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Link", fmt.Sprintf("<%s/v1/experiments/fixture/trials>; rel=https://stormforge.io/rel/trials", srv.URL))
+		_, _ = fmt.Fprint(w, `{"displayName": "fixture"}`)
+	})
+	mux.HandleFunc("/v1/experiments/fixture/trials", func(w http.ResponseWriter, r *http.Request) {
+		offset := r.URL.Query().Get("offset")
+		pages = append(pages, offset)
+		next := map[string]string{"": "2", "2": "4", "4": ""}[offset]
+		if next != "" {
+			w.Header().Set("Link", fmt.Sprintf("</v1/experiments/fixture/trials?offset=%s>; rel=next", next))
+		}
+		n := map[string]int{"": 6, "2": 4, "4": 2}[offset]
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"trials": [{"number": %d}, {"number": %d}]}`, n, n-1)
+	})
+	srv = httptest.NewServer(mux)
+	defer srv.Close()
+
+	client, err := api.NewClient(srv.URL, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	cases := []struct {
+		desc     string
+		names    []string
+		maxItems int
+		visited  []int64
+		pages    []string
+	}{
+		{
+			desc:    "unlimited",
+			names:   []string{"fixture"},
+			visited: []int64{6, 5, 4, 3, 2, 1},
+			pages:   []string{"", "2", "4"},
+		},
+		{
+			desc:     "across pages",
+			names:    []string{"fixture"},
+			maxItems: 3,
+			visited:  []int64{6, 5, 4},
+			pages:    []string{"", "2"},
+		},
+		{
+			desc:     "page boundary",
+			names:    []string{"fixture"},
+			maxItems: 2,
+			visited:  []int64{6, 5},
+			pages:    []string{""},
+		},
+		{
+			desc:     "named trial",
+			names:    []string{"fixture/1"},
+			maxItems: 1,
+			visited:  []int64{1},
+			pages:    []string{"", "2", "4"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			pages = nil
+			l := Lister{API: NewAPI(client), MaxItems: c.maxItems}
+			var visited []int64
+			err := l.ForEachNamedTrial(context.Background(), c.names, TrialListQuery{}, false, func(item *TrialItem) error {
+				visited = append(visited, item.Number)
+				return nil
+			})
+			if assert.NoError(t, err) {
+				assert.Equal(t, c.visited, visited)
+				assert.Equal(t, c.pages, pages)
+			}
+		})
+	}
+}
+
+func TestLister_ForEachNamedExperiment_MaxItems(t *testing.T) {
+	fake := &fakeTrialsAPI{trials: map[ExperimentName][]TrialItem{
+		"one":   {},
+		"two":   {},
+		"three": {},
+	}}
+
+	var progress [][2]int
+	l := Lister{
+		API:      fake,
+		MaxItems: 2,
+		Progress: func(visited, total int) { progress = append(progress, [2]int{visited, total}) },
+	}
+
+	var visited []ExperimentName
+	err := l.ForEachNamedExperiment(context.Background(), []string{"one", "two", "three"}, false, func(item *ExperimentItem) error {
+		visited = append(visited, item.Name)
+		return nil
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, []ExperimentName{"one", "two"}, visited)
+		assert.Equal(t, [][2]int{{1, 2}, {2, 2}}, progress)
+		assert.NotContains(t, fake.fetched, ExperimentName("three"))
+	}
+}
+
+// fakeTrialsAPI serves trials for experiments from memory, tracking concurrent requests.
+type fakeTrialsAPI struct {
+	API
+	trials map[ExperimentName][]TrialItem
+
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+	fetched  map[ExperimentName]int
+}
+
+func (f *fakeTrialsAPI) GetExperimentByName(ctx context.Context, n ExperimentName) (Experiment, error) {
+	f.mu.Lock()
+	if f.fetched == nil {
+		f.fetched = make(map[ExperimentName]int)
+	}
+	f.fetched[n]++
+	f.mu.Unlock()
+
+	if _, ok := f.trials[n]; !ok {
+		return Experiment{}, &api.Error{Type: ErrExperimentNotFound}
+	}
+	return Experiment{Name: n, Metadata: api.Metadata{"Link": {"</" + n.String() + "/trials>; rel=https://stormforge.io/rel/trials"}}}, nil
+}
+
+func (f *fakeTrialsAPI) GetAllTrials(ctx context.Context, u string, q TrialListQuery) (TrialList, error) {
+	f.mu.Lock()
+	f.inFlight++
+	if f.inFlight > f.peak {
+		f.peak = f.inFlight
+	}
+	f.mu.Unlock()
+
+	time.Sleep(50 * time.Millisecond)
+
+	f.mu.Lock()
+	f.inFlight--
+	f.mu.Unlock()
+
+	n := ExperimentName(strings.TrimSuffix(strings.TrimPrefix(u, "/"), "/trials"))
+	return TrialList{Trials: append([]TrialItem(nil), f.trials[n]...)}, nil
+}
+
+func TestLister_ForEachNamedTrial_Concurrency(t *testing.T) {
+	fake := &fakeTrialsAPI{trials: map[ExperimentName][]TrialItem{
+		"one":   {{Number: 1}, {Number: 2}},
+		"two":   {{Number: 1}},
+		"three": {{Number: 1}, {Number: 2}, {Number: 3}},
+	}}
+	l := Lister{API: fake, Concurrency: 3}
+
+	var visited []string
+	err := l.ForEachNamedTrial(context.Background(), []string{"two", "missing", "one", "three/2"}, TrialListQuery{}, true, func(item *TrialItem) error {
+		visited = append(visited, JoinTrialName(item.Experiment, item.Number))
+		return nil
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"two-001", "one-002", "one-001", "three-002"}, visited)
+		assert.Greater(t, fake.peak, 1, "trials were not fetched in parallel")
+	}
+
+	// Not found experiments fail when they are not ignored
+	err = l.ForEachNamedTrial(context.Background(), []string{"one", "missing"}, TrialListQuery{}, false, func(item *TrialItem) error { return nil })
+	var apiErr *api.Error
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, ErrExperimentNotFound, apiErr.Type)
+	}
+}
+
+func TestLister_Progress(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		offset := r.URL.Query().Get("offset")
+		next := map[string]string{"": "2", "2": "4", "4": ""}[offset]
+		if next != "" {
+			w.Header().Set("Link", fmt.Sprintf("</v1/experiments/?offset=%s>; rel=next", next))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"totalCount": 6, "experiments": [{"displayName": "a"}, {"displayName": "b"}]}`)
+	}))
+	defer srv.Close()
+
+	client, err := api.NewClient(srv.URL, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	cases := []struct {
+		desc     string
+		maxItems int
+		progress [][2]int
+	}{
+		{
+			desc:     "total",
+			progress: [][2]int{{1, 6}, {2, 6}, {3, 6}, {4, 6}, {5, 6}, {6, 6}},
+		},
+		{
+			desc:     "limited total",
+			maxItems: 3,
+			progress: [][2]int{{1, 3}, {2, 3}, {3, 3}},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			var progress [][2]int
+			l := Lister{
+				API:      NewAPI(client),
+				MaxItems: c.maxItems,
+				Progress: func(visited, total int) { progress = append(progress, [2]int{visited, total}) },
+			}
+			err := l.ForEachExperiment(context.Background(), ExperimentListQuery{}, func(*ExperimentItem) error { return nil })
+			if assert.NoError(t, err) {
+				assert.Equal(t, c.progress, progress)
+			}
+		})
+	}
+}
+
+func TestLister_ExperimentCache(t *testing.T) {
+	fake := &fakeTrialsAPI{trials: map[ExperimentName][]TrialItem{
+		"one": {{Number: 1}, {Number: 2}, {Number: 3}},
+		"two": {{Number: 1}},
+	}}
+	l := Lister{API: fake, Concurrency: 2}
+
+	var visited int
+	err := l.ForEachNamedTrial(context.Background(), []string{"one-001", "one-002", "two-001", "one-003", "one"}, TrialListQuery{}, false, func(*TrialItem) error {
+		visited++
+		return nil
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	err = l.ForEachNamedExperiment(context.Background(), []string{"one", "two", "one"}, false, func(*ExperimentItem) error {
+		visited++
+		return nil
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, 10, visited)
+		assert.Equal(t, map[ExperimentName]int{"one": 1, "two": 1}, fake.fetched)
+	}
+}
+
+func TestLister_ForEachTrial_TimeRange(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "2022-03-01T00:00:00Z", r.URL.Query().Get(ParamCompletedAfter))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"trials": [
+  {"number": 1, "completionTime": "2022-02-28T12:00:00Z"},
+  {"number": 2, "completionTime": "2022-03-01T12:00:00Z"},
+  {"number": 3}
+]}`)
+	}))
+	defer srv.Close()
+
+	client, err := api.NewClient(srv.URL, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// The time range is ignored by the server, make sure we still filter the trials
+	exp := &Experiment{Metadata: api.Metadata{"Link": {"<" + srv.URL + "/v1/experiments/test/trials>; rel=https://stormforge.io/rel/trials"}}}
+	q := TrialListQuery{}
+	q.SetCompletedAfter(time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC))
+
+	var visited []int64
+	err = (&Lister{API: NewAPI(client)}).ForEachTrial(context.Background(), exp, q, func(item *TrialItem) error {
+		visited = append(visited, item.Number)
+		return nil
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, []int64{2}, visited)
+	}
+}
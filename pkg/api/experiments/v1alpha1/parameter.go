@@ -218,3 +218,55 @@ func CheckParameterConstraints(assignments []Assignment, constraints []Constrain
 
 	return nil
 }
+
+// String returns a human readable representation of the constraint expression.
+func (c *Constraint) String() string {
+	switch {
+	case c.ConstraintType == ConstraintOrder && c.OrderConstraint != nil:
+		return c.LowerParameter + " <= " + c.UpperParameter
+
+	case c.ConstraintType == ConstraintSum && c.SumConstraint != nil:
+		var expr strings.Builder
+		for i, p := range c.SumConstraint.Parameters {
+			w := p.Weight
+			switch {
+			case i > 0 && w < 0:
+				expr.WriteString(" - ")
+				w = -w
+			case i > 0:
+				expr.WriteString(" + ")
+			case w < 0:
+				expr.WriteString("-")
+				w = -w
+			}
+			if w != 1 {
+				expr.WriteString(strconv.FormatFloat(w, 'g', -1, 64) + "*")
+			}
+			expr.WriteString(p.ParameterName)
+		}
+
+		op := " >= "
+		if c.IsUpperBound {
+			op = " <= "
+		}
+		return expr.String() + op + strconv.FormatFloat(c.Bound, 'g', -1, 64)
+
+	default:
+		return string(c.ConstraintType)
+	}
+}
+
+// References checks if the constraint involves the named parameter.
+func (c *Constraint) References(parameterName string) bool {
+	switch {
+	case c.ConstraintType == ConstraintOrder && c.OrderConstraint != nil:
+		return c.LowerParameter == parameterName || c.UpperParameter == parameterName
+	case c.ConstraintType == ConstraintSum && c.SumConstraint != nil:
+		for _, p := range c.SumConstraint.Parameters {
+			if p.ParameterName == parameterName {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thestormforge/optimize-go/pkg/api"
+)
+
+func TestCheckParameterConstraints(t *testing.T) {
+	constraints := []Constraint{
+		{
+			Name:           "budget",
+			ConstraintType: ConstraintSum,
+			SumConstraint: &SumConstraint{
+				IsUpperBound: true,
+				Bound:        100,
+				Parameters:   []SumConstraintParameter{{ParameterName: "a", Weight: 1}, {ParameterName: "b", Weight: 1}},
+			},
+		},
+	}
+
+	cases := []struct {
+		desc        string
+		assignments []Assignment
+		err         string
+	}{
+		{
+			desc: "satisfied",
+			assignments: []Assignment{
+				{ParameterName: "a", Value: api.FromInt64(40)},
+				{ParameterName: "b", Value: api.FromInt64(60)},
+			},
+		},
+		{
+			desc: "violated",
+			assignments: []Assignment{
+				{ParameterName: "a", Value: api.FromInt64(40)},
+				{ParameterName: "b", Value: api.FromInt64(61)},
+			},
+			err: `assignment does not satisfy constraint "budget"`,
+		},
+		{
+			desc: "missing parameter",
+			assignments: []Assignment{
+				{ParameterName: "a", Value: api.FromInt64(40)},
+			},
+			err: `constraint "budget" references missing parameter "b"`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			err := CheckParameterConstraints(c.assignments, constraints)
+			if c.err != "" {
+				assert.EqualError(t, err, c.err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestConstraint_String(t *testing.T) {
+	cases := []struct {
+		desc       string
+		constraint Constraint
+		expected   string
+	}{
+		{
+			desc: "sum upper bound",
+			constraint: Constraint{ConstraintType: ConstraintSum, SumConstraint: &SumConstraint{
+				IsUpperBound: true,
+				Bound:        100,
+				Parameters:   []SumConstraintParameter{{ParameterName: "a", Weight: 1}, {ParameterName: "b", Weight: 2.5}},
+			}},
+			expected: "a + 2.5*b <= 100",
+		},
+		{
+			desc: "sum lower bound",
+			constraint: Constraint{ConstraintType: ConstraintSum, SumConstraint: &SumConstraint{
+				Bound:      -1,
+				Parameters: []SumConstraintParameter{{ParameterName: "a", Weight: -1}, {ParameterName: "b", Weight: -3}},
+			}},
+			expected: "-a - 3*b >= -1",
+		},
+		{
+			desc:       "order",
+			constraint: Constraint{ConstraintType: ConstraintOrder, OrderConstraint: &OrderConstraint{LowerParameter: "min", UpperParameter: "max"}},
+			expected:   "min <= max",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			assert.Equal(t, c.expected, c.constraint.String())
+		})
+	}
+}
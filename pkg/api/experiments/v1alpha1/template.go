@@ -0,0 +1,76 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+// ApplyTemplate produces the experiment to create from template parameters and
+// metrics merged with user supplied overrides. Parameters and metrics are matched
+// by name: the overrides take precedence over the template and anything not
+// present in the template is appended. All other experiment fields are taken
+// from the overrides.
+func ApplyTemplate(parameters []Parameter, metrics []Metric, overrides Experiment) Experiment {
+	exp := overrides
+	exp.Parameters = nil
+	exp.Metrics = nil
+
+	// Index the overrides by name so they can be matched against the template
+	paramOverrides := make(map[string]*Parameter, len(overrides.Parameters))
+	for i := range overrides.Parameters {
+		paramOverrides[overrides.Parameters[i].Name] = &overrides.Parameters[i]
+	}
+	metricOverrides := make(map[string]*Metric, len(overrides.Metrics))
+	for i := range overrides.Metrics {
+		metricOverrides[overrides.Metrics[i].Name] = &overrides.Metrics[i]
+	}
+
+	for _, p := range parameters {
+		if op, ok := paramOverrides[p.Name]; ok {
+			if op.Type != "" {
+				p.Type = op.Type
+			}
+			if op.Bounds != nil {
+				p.Bounds = op.Bounds
+			}
+			if len(op.Values) > 0 {
+				p.Values = op.Values
+			}
+			delete(paramOverrides, p.Name)
+		}
+
+		exp.Parameters = append(exp.Parameters, p)
+	}
+	for _, op := range overrides.Parameters {
+		if _, ok := paramOverrides[op.Name]; ok {
+			exp.Parameters = append(exp.Parameters, op)
+		}
+	}
+
+	for _, m := range metrics {
+		if om, ok := metricOverrides[m.Name]; ok {
+			m = *om
+			delete(metricOverrides, m.Name)
+		}
+
+		exp.Metrics = append(exp.Metrics, m)
+	}
+	for _, om := range overrides.Metrics {
+		if _, ok := metricOverrides[om.Name]; ok {
+			exp.Metrics = append(exp.Metrics, om)
+		}
+	}
+
+	return exp
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyTemplate(t *testing.T) {
+	parameters := []Parameter{
+		{Name: "cpu", Type: ParameterTypeInteger, Bounds: &Bounds{Min: "100", Max: "4000"}},
+		{Name: "memory", Type: ParameterTypeInteger, Bounds: &Bounds{Min: "128", Max: "4096"}},
+	}
+	metrics := []Metric{
+		{Name: "cost", Minimize: true},
+		{Name: "duration", Minimize: true},
+	}
+
+	overrides := Experiment{
+		DisplayName: "Narrowed",
+		Parameters: []Parameter{
+			{Name: "cpu", Bounds: &Bounds{Min: "500", Max: "1000"}},
+			{Name: "gc", Type: ParameterTypeCategorical, Values: []string{"G1", "Parallel"}},
+		},
+		Metrics: []Metric{
+			{Name: "duration", Optimize: new(bool)},
+		},
+	}
+
+	exp := ApplyTemplate(parameters, metrics, overrides)
+
+	assert.Equal(t, "Narrowed", exp.DisplayName)
+	assert.Equal(t, []Parameter{
+		{Name: "cpu", Type: ParameterTypeInteger, Bounds: &Bounds{Min: "500", Max: "1000"}},
+		{Name: "memory", Type: ParameterTypeInteger, Bounds: &Bounds{Min: "128", Max: "4096"}},
+		{Name: "gc", Type: ParameterTypeCategorical, Values: []string{"G1", "Parallel"}},
+	}, exp.Parameters)
+	assert.Equal(t, []Metric{
+		{Name: "cost", Minimize: true},
+		{Name: "duration", Optimize: new(bool)},
+	}, exp.Metrics)
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"fmt"
 	"net/url"
 	"strings"
 	"time"
@@ -74,6 +75,40 @@ const (
 	TrialAbandoned TrialStatus = "abandoned"
 )
 
+// StatusCounts returns the number of trials in each status. All of the known statuses are
+// included in the result, even if there are no trials with that status.
+func StatusCounts(trials []TrialItem) map[string]int {
+	counts := map[string]int{
+		string(TrialStaged):    0,
+		string(TrialActive):    0,
+		string(TrialCompleted): 0,
+		string(TrialFailed):    0,
+		string(TrialAbandoned): 0,
+	}
+	for i := range trials {
+		counts[string(trials[i].Status)]++
+	}
+	return counts
+}
+
+// ParseTrialStatus returns the trial status corresponding to the supplied string.
+func ParseTrialStatus(s string) (TrialStatus, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "staged":
+		return TrialStaged, nil
+	case "active", "running":
+		return TrialActive, nil
+	case "completed", "complete":
+		return TrialCompleted, nil
+	case "failed":
+		return TrialFailed, nil
+	case "abandoned", "aborted":
+		return TrialAbandoned, nil
+	default:
+		return "", fmt.Errorf("unknown trial status %q", s)
+	}
+}
+
 type TrialListQuery struct{ api.IndexQuery }
 
 func (q *TrialListQuery) SetStatus(status ...TrialStatus) {
@@ -100,6 +135,63 @@ func (q *TrialListQuery) AddStatus(status TrialStatus) {
 	url.Values(q.IndexQuery).Set("status", value)
 }
 
+const (
+	// ParamCreatedAfter is the query parameter for the lower bound of the trial start time.
+	ParamCreatedAfter = "createdAfter"
+	// ParamCreatedBefore is the query parameter for the upper bound of the trial start time.
+	ParamCreatedBefore = "createdBefore"
+	// ParamCompletedAfter is the query parameter for the lower bound of the trial completion time.
+	ParamCompletedAfter = "completedAfter"
+	// ParamCompletedBefore is the query parameter for the upper bound of the trial completion time.
+	ParamCompletedBefore = "completedBefore"
+)
+
+// SetCreatedAfter restricts the query to trials started at or after the supplied time.
+func (q *TrialListQuery) SetCreatedAfter(t time.Time) { q.setTime(ParamCreatedAfter, t) }
+
+// SetCreatedBefore restricts the query to trials started before the supplied time.
+func (q *TrialListQuery) SetCreatedBefore(t time.Time) { q.setTime(ParamCreatedBefore, t) }
+
+// SetCompletedAfter restricts the query to trials completed at or after the supplied time.
+func (q *TrialListQuery) SetCompletedAfter(t time.Time) { q.setTime(ParamCompletedAfter, t) }
+
+// SetCompletedBefore restricts the query to trials completed before the supplied time.
+func (q *TrialListQuery) SetCompletedBefore(t time.Time) { q.setTime(ParamCompletedBefore, t) }
+
+func (q *TrialListQuery) setTime(key string, t time.Time) {
+	if t.IsZero() {
+		url.Values(q.IndexQuery).Del(key)
+		return
+	}
+	if q.IndexQuery == nil {
+		q.IndexQuery = api.IndexQuery{}
+	}
+	url.Values(q.IndexQuery).Set(key, t.UTC().Format(time.RFC3339))
+}
+
+// MatchesTimeRange checks the trial timestamps against the time range of the query. This
+// can be used to filter trials when the server does not support time range parameters.
+func (q *TrialListQuery) MatchesTimeRange(item *TrialItem) bool {
+	return inTimeRange(item.StartTime, q.time(ParamCreatedAfter), q.time(ParamCreatedBefore)) &&
+		inTimeRange(item.CompletionTime, q.time(ParamCompletedAfter), q.time(ParamCompletedBefore))
+}
+
+func (q *TrialListQuery) time(key string) time.Time {
+	t, _ := time.Parse(time.RFC3339, url.Values(q.IndexQuery).Get(key))
+	return t
+}
+
+// inTimeRange checks a timestamp against optional bounds, a missing timestamp never matches a bound.
+func inTimeRange(t *time.Time, after, before time.Time) bool {
+	if after.IsZero() && before.IsZero() {
+		return true
+	}
+	if t == nil {
+		return false
+	}
+	return (after.IsZero() || !t.Before(after)) && (before.IsZero() || t.Before(before))
+}
+
 type TrialItem struct {
 	TrialAssignments
 	TrialValues
@@ -122,6 +214,8 @@ func (ti *TrialItem) UnmarshalJSON(b []byte) error {
 type TrialList struct {
 	// The trial list metadata.
 	api.Metadata `json:"-"`
+	// The total number of items in the collection.
+	TotalCount int `json:"totalCount,omitempty"`
 	// The list of trials.
 	Trials []TrialItem `json:"trials"`
 
@@ -130,6 +224,9 @@ type TrialList struct {
 	Experiment *Experiment `json:"-"`
 }
 
+// LabelBaseline is the trial label used to identify the baseline trial.
+const LabelBaseline = "baseline"
+
 type TrialLabels struct {
 	// New labels for this trial.
 	Labels map[string]string `json:"labels"`
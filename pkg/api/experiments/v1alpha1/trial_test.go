@@ -1,8 +1,15 @@
 package v1alpha1
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/thestormforge/optimize-go/pkg/api"
@@ -56,3 +63,243 @@ func TestTrialList_UnmarshalJSON(t *testing.T) {
 		assert.Equal(t, "true", l.Trials[1].Labels["manually_created"])
 	}
 }
+
+func TestParseTrialStatus(t *testing.T) {
+	cases := []struct {
+		desc     string
+		status   string
+		expected TrialStatus
+		err      bool
+	}{
+		{desc: "staged", status: "staged", expected: TrialStaged},
+		{desc: "running alias", status: "Running", expected: TrialActive},
+		{desc: "completed", status: "completed", expected: TrialCompleted},
+		{desc: "failed", status: "failed", expected: TrialFailed},
+		{desc: "abandoned", status: "abandoned", expected: TrialAbandoned},
+		{desc: "aborted alias", status: " aborted ", expected: TrialAbandoned},
+		{desc: "unknown", status: "pending", err: true},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			actual, err := ParseTrialStatus(c.status)
+			if c.err {
+				assert.Error(t, err)
+			} else if assert.NoError(t, err) {
+				assert.Equal(t, c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestStatusCounts(t *testing.T) {
+	trials := []TrialItem{
+		{Status: TrialCompleted},
+		{Status: TrialFailed},
+		{Status: TrialCompleted},
+		{Status: TrialActive},
+		{Status: TrialCompleted},
+		{Status: TrialAbandoned},
+	}
+	assert.Equal(t, map[string]int{
+		"staged":    0,
+		"active":    1,
+		"completed": 3,
+		"failed":    1,
+		"abandoned": 1,
+	}, StatusCounts(trials))
+}
+
+func TestTrialListQuery_TimeRange(t *testing.T) {
+	est := time.FixedZone("EST", -5*60*60)
+	q := TrialListQuery{}
+	q.SetCreatedAfter(time.Date(2022, 3, 1, 7, 0, 0, 0, est))
+	q.SetCreatedBefore(time.Date(2022, 3, 2, 0, 0, 0, 0, time.UTC))
+	q.SetCompletedAfter(time.Date(2022, 3, 1, 12, 30, 0, 0, time.UTC))
+	q.SetCompletedBefore(time.Time{})
+	assert.Equal(t, url.Values{
+		"createdAfter":   {"2022-03-01T12:00:00Z"},
+		"createdBefore":  {"2022-03-02T00:00:00Z"},
+		"completedAfter": {"2022-03-01T12:30:00Z"},
+	}, url.Values(q.IndexQuery))
+
+	ts := func(s string) *time.Time {
+		t, _ := time.Parse(time.RFC3339, s)
+		return &t
+	}
+	cases := []struct {
+		desc      string
+		started   *time.Time
+		completed *time.Time
+		expected  bool
+	}{
+		{
+			desc:      "in range",
+			started:   ts("2022-03-01T12:00:00Z"),
+			completed: ts("2022-03-01T13:00:00Z"),
+			expected:  true,
+		},
+		{
+			desc:      "started too early",
+			started:   ts("2022-03-01T11:59:59Z"),
+			completed: ts("2022-03-01T13:00:00Z"),
+		},
+		{
+			desc:      "started too late",
+			started:   ts("2022-03-02T00:00:00Z"),
+			completed: ts("2022-03-02T01:00:00Z"),
+		},
+		{
+			desc:      "completed too early",
+			started:   ts("2022-03-01T12:00:00Z"),
+			completed: ts("2022-03-01T12:29:00Z"),
+		},
+		{
+			desc:    "not completed",
+			started: ts("2022-03-01T12:00:00Z"),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			item := &TrialItem{TrialValues: TrialValues{StartTime: c.started, CompletionTime: c.completed}}
+			assert.Equal(t, c.expected, q.MatchesTimeRange(item))
+		})
+	}
+
+	// An empty query matches everything
+	assert.True(t, (&TrialListQuery{}).MatchesTimeRange(&TrialItem{}))
+}
+
+func TestHTTPAPI_ReportTrial_Failed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if assert.NoError(t, err) {
+			assert.JSONEq(t, `{"failed":true,"failureReason":"OOMKilled","failureMessage":"the container ran out of memory"}`, string(body))
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	client, err := api.NewClient(srv.URL, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	err = NewAPI(client).ReportTrial(context.Background(), client.URL("/v1/experiments/test/trials/1").String(), TrialValues{
+		Values:         []Value{{MetricName: "cost", Value: 1}},
+		Failed:         true,
+		FailureReason:  "OOMKilled",
+		FailureMessage: "the container ran out of memory",
+	})
+	assert.NoError(t, err)
+}
+
+func TestHTTPAPI_UpdateTrialValues(t *testing.T) {
+	var values []Value
+	var version int
+	var reported bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("ETag", fmt.Sprintf(`"%d"`, version))
+			_ = json.NewEncoder(w).Encode(TrialItem{Number: 1, Status: TrialActive, TrialValues: TrialValues{Values: values}})
+			return
+		}
+		if reported {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+
+		vls := TrialValues{}
+		if !assert.NoError(t, json.NewDecoder(r.Body).Decode(&vls)) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodPatch:
+			assert.Equal(t, "application/merge-patch+json", r.Header.Get("Content-Type"))
+			assert.Equal(t, fmt.Sprintf(`"%d"`, version), r.Header.Get("If-Match"))
+
+			// Merge-patch semantics replace the entire array
+			values = vls.Values
+			version++
+			w.WriteHeader(http.StatusNoContent)
+		case http.MethodPost:
+			values = vls.Values
+			reported = true
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	defer srv.Close()
+
+	client, err := api.NewClient(srv.URL, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	expAPI := NewAPI(client)
+	ctx, u := context.Background(), client.URL("/v1/experiments/test/trials/1").String()
+
+	assert.NoError(t, expAPI.UpdateTrialValues(ctx, u, []Value{{MetricName: "cost", Value: 10}, {MetricName: "duration", Value: 5}}))
+	assert.NoError(t, expAPI.UpdateTrialValues(ctx, u, []Value{{MetricName: "cost", Value: 12}, {MetricName: "latency", Value: 100}}))
+	assert.Equal(t, []Value{{MetricName: "cost", Value: 12}, {MetricName: "duration", Value: 5}, {MetricName: "latency", Value: 100}}, values)
+	assert.False(t, reported)
+
+	assert.NoError(t, expAPI.FinalizeTrial(ctx, u, TrialValues{Values: []Value{{MetricName: "throughput", Value: 50}}}))
+	assert.True(t, reported)
+	assert.Equal(t, []Value{{MetricName: "cost", Value: 12}, {MetricName: "duration", Value: 5}, {MetricName: "latency", Value: 100}, {MetricName: "throughput", Value: 50}}, values)
+
+	err = expAPI.UpdateTrialValues(ctx, u, []Value{{MetricName: "cost", Value: 1}})
+	var apiErr *api.Error
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, ErrTrialAlreadyReported, apiErr.Type)
+	}
+}
+
+func TestHTTPAPI_PeekNextTrial(t *testing.T) {
+	cases := []struct {
+		desc     string
+		status   int
+		expected []Assignment
+		err      api.ErrorType
+	}{
+		{
+			desc:     "supported",
+			status:   http.StatusOK,
+			expected: []Assignment{{ParameterName: "cpu", Value: api.FromInt64(500)}},
+		},
+		{
+			desc:   "unsupported",
+			status: http.StatusMethodNotAllowed,
+			err:    ErrNotSupported,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodGet, r.Method)
+				if c.status != http.StatusOK {
+					w.WriteHeader(c.status)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"assignments": [{"parameterName": "cpu", "value": 500}]}`))
+			}))
+			defer srv.Close()
+
+			client, err := api.NewClient(srv.URL, nil)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			asm, err := NewAPI(client).PeekNextTrial(context.Background(), client.URL("/v1/experiments/test/nextTrial").String())
+			if c.err != "" {
+				var apiErr *api.Error
+				if assert.ErrorAs(t, err, &apiErr) {
+					assert.Equal(t, c.err, apiErr.Type)
+				}
+			} else if assert.NoError(t, err) {
+				assert.Equal(t, c.expected, asm.Assignments)
+			}
+		})
+	}
+}
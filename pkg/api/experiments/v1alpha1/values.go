@@ -0,0 +1,68 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// ToValuesYAML renders trial assignments as a nested YAML document (e.g. Helm values). The
+// mapping associates parameter names with dotted keys (e.g. "resources.requests.cpu");
+// parameters without a mapping use their name as a top-level key. Numeric assignments are
+// rendered as numbers and string assignments as strings.
+func ToValuesYAML(asm TrialAssignments, mapping map[string]string) ([]byte, error) {
+	values := make(map[string]interface{})
+	for _, a := range asm.Assignments {
+		key := a.ParameterName
+		if k, ok := mapping[key]; ok {
+			key = k
+		}
+
+		path := strings.Split(key, ".")
+		for _, p := range path {
+			if p == "" {
+				return nil, fmt.Errorf("invalid key %q for parameter %q", key, a.ParameterName)
+			}
+		}
+
+		// Walk down to the parent of the leaf, creating intermediate maps as necessary
+		m := values
+		for _, p := range path[:len(path)-1] {
+			switch v := m[p].(type) {
+			case nil:
+				child := make(map[string]interface{})
+				m[p] = child
+				m = child
+			case map[string]interface{}:
+				m = v
+			default:
+				return nil, fmt.Errorf("key %q for parameter %q conflicts with another parameter", key, a.ParameterName)
+			}
+		}
+
+		leaf := path[len(path)-1]
+		if _, ok := m[leaf]; ok {
+			return nil, fmt.Errorf("key %q for parameter %q conflicts with another parameter", key, a.ParameterName)
+		}
+		m[leaf] = a.Value
+	}
+
+	return yaml.Marshal(values)
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thestormforge/optimize-go/pkg/api"
+)
+
+func TestToValuesYAML(t *testing.T) {
+	cases := []struct {
+		desc        string
+		assignments []Assignment
+		mapping     map[string]string
+		expected    string
+		err         bool
+	}{
+		{
+			desc: "nested keys",
+			assignments: []Assignment{
+				{ParameterName: "cpu", Value: api.FromInt64(500)},
+				{ParameterName: "memory", Value: api.FromInt64(2048)},
+				{ParameterName: "replicas", Value: api.FromInt64(3)},
+			},
+			mapping: map[string]string{
+				"cpu":    "resources.requests.cpu",
+				"memory": "resources.requests.memory",
+			},
+			expected: `replicas: 3
+resources:
+  requests:
+    cpu: 500
+    memory: 2048
+`,
+		},
+		{
+			desc: "type preservation",
+			assignments: []Assignment{
+				{ParameterName: "ratio", Value: api.FromFloat64(0.75)},
+				{ParameterName: "gc", Value: api.FromString("G1")},
+				{ParameterName: "flag", Value: api.FromString("true")},
+			},
+			mapping: map[string]string{
+				"ratio": "jvm.ratio",
+				"gc":    "jvm.gc",
+			},
+			expected: `flag: "true"
+jvm:
+  gc: G1
+  ratio: 0.75
+`,
+		},
+		{
+			desc: "conflict",
+			assignments: []Assignment{
+				{ParameterName: "a", Value: api.FromInt64(1)},
+				{ParameterName: "b", Value: api.FromInt64(2)},
+			},
+			mapping: map[string]string{
+				"b": "a.b",
+			},
+			err: true,
+		},
+		{
+			desc: "empty segment",
+			assignments: []Assignment{
+				{ParameterName: "a", Value: api.FromInt64(1)},
+			},
+			mapping: map[string]string{
+				"a": "x..y",
+			},
+			err: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			actual, err := ToValuesYAML(TrialAssignments{Assignments: c.assignments}, c.mapping)
+			if c.err {
+				assert.Error(t, err)
+			} else if assert.NoError(t, err) {
+				assert.Equal(t, c.expected, string(actual))
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// WithHedging sends a second, identical request if a safe request (e.g. a GET) has not
+// completed within the specified delay. The first successful response is used and the
+// other request is canceled. Requests with a body are never hedged.
+func WithHedging(delay time.Duration) ClientOption {
+	return func(o *clientOptions) { o.hedgeDelay = delay }
+}
+
+// hedged executes a single HTTP request, possibly racing it against a second request.
+func (c *httpClient) hedged(ctx context.Context, req *http.Request) (*http.Response, []byte, error) {
+	if c.hedge <= 0 || !isSafeMethod(req.Method) || (req.Body != nil && req.Body != http.NoBody) {
+		return c.do(ctx, req)
+	}
+
+	type result struct {
+		resp *http.Response
+		body []byte
+		err  error
+	}
+
+	// Each request gets its own context so the loser can be canceled
+	results := make(chan result, 2)
+	var cancels []context.CancelFunc
+	defer func() {
+		for _, cancel := range cancels {
+			cancel()
+		}
+	}()
+	send := func() {
+		ctx, cancel := context.WithCancel(ctx)
+		cancels = append(cancels, cancel)
+		go func() {
+			resp, body, err := c.do(ctx, req.Clone(ctx))
+			results <- result{resp: resp, body: body, err: err}
+		}()
+	}
+
+	send()
+	pending := 1
+	timer := time.NewTimer(c.hedge)
+	defer timer.Stop()
+	hedge := timer.C
+	for {
+		select {
+		case <-hedge:
+			hedge = nil
+			send()
+			pending++
+		case r := <-results:
+			pending--
+			if r.err == nil || pending == 0 {
+				return r.resp, r.body, r.err
+			}
+		}
+	}
+}
+
+// isSafeMethod checks for HTTP methods which are safe to send more than once.
+func isSafeMethod(method string) bool {
+	switch method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
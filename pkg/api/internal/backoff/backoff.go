@@ -0,0 +1,84 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package backoff implements exponential backoff with full jitter.
+package backoff
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Backoff produces a sequence of randomized, exponentially increasing delays.
+// The zero value is usable and produces delays starting at one second, capped
+// at one minute.
+type Backoff struct {
+	// The base delay for the first attempt. Defaults to 1 second.
+	Base time.Duration
+	// The maximum delay for any attempt. Defaults to 1 minute.
+	Max time.Duration
+	// The multiplier applied to the delay after each attempt. Defaults to 2.
+	Factor float64
+	// The source of randomness, returns a value in [0.0,1.0). Defaults to `rand.Float64`.
+	Rand func() float64
+
+	// The number of delays produced since the last reset.
+	attempt int
+}
+
+// Next returns the delay for the next attempt. The returned value is chosen
+// uniformly from the range between zero and the (capped) exponential delay.
+func (b *Backoff) Next() time.Duration {
+	d := b.Ceiling()
+	b.attempt++
+
+	r := rand.Float64
+	if b.Rand != nil {
+		r = b.Rand
+	}
+	return time.Duration(r() * float64(d))
+}
+
+// Ceiling returns the upper bound of the delay for the next attempt.
+func (b *Backoff) Ceiling() time.Duration {
+	base, max, factor := b.Base, b.Max, b.Factor
+	if base <= 0 {
+		base = time.Second
+	}
+	if max <= 0 {
+		max = time.Minute
+	}
+	if factor < 1 {
+		factor = 2
+	}
+
+	d := float64(base) * math.Pow(factor, float64(b.attempt))
+	if d > float64(max) || math.IsInf(d, 0) || math.IsNaN(d) {
+		return max
+	}
+	return time.Duration(d)
+}
+
+// Attempt returns the number of delays produced since the last reset.
+func (b *Backoff) Attempt() int {
+	return b.attempt
+}
+
+// Reset restarts the sequence of delays from the base delay.
+func (b *Backoff) Reset() {
+	b.attempt = 0
+}
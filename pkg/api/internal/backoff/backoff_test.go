@@ -0,0 +1,83 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backoff
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoff_Ceiling(t *testing.T) {
+	b := &Backoff{Base: 100 * time.Millisecond, Max: time.Second}
+	var actual []time.Duration
+	for i := 0; i < 6; i++ {
+		actual = append(actual, b.Ceiling())
+		b.Next()
+	}
+	assert.Equal(t, []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second,
+		time.Second,
+	}, actual)
+	assert.Equal(t, 6, b.Attempt())
+
+	b.Reset()
+	assert.Equal(t, 0, b.Attempt())
+	assert.Equal(t, 100*time.Millisecond, b.Ceiling())
+}
+
+func TestBackoff_Defaults(t *testing.T) {
+	b := &Backoff{}
+	assert.Equal(t, time.Second, b.Ceiling())
+	for i := 0; i < 100; i++ {
+		b.Next()
+	}
+	assert.Equal(t, time.Minute, b.Ceiling(), "ceiling should not overflow")
+}
+
+func TestBackoff_Next(t *testing.T) {
+	t.Run("bounds", func(t *testing.T) {
+		b := &Backoff{Base: 10 * time.Millisecond, Max: 80 * time.Millisecond, Factor: 3}
+		for i := 0; i < 1000; i++ {
+			if i%10 == 0 {
+				b.Reset()
+			}
+			ceiling := b.Ceiling()
+			d := b.Next()
+			assert.GreaterOrEqual(t, d, time.Duration(0))
+			assert.Less(t, d, ceiling)
+			assert.LessOrEqual(t, d, 80*time.Millisecond)
+		}
+	})
+
+	t.Run("full jitter", func(t *testing.T) {
+		r := []float64{0, 0.5, 0.999}
+		b := &Backoff{Base: time.Second, Max: time.Hour, Rand: func() float64 {
+			v := r[0]
+			r = r[1:]
+			return v
+		}}
+		assert.Equal(t, time.Duration(0), b.Next())
+		assert.Equal(t, time.Second, b.Next())
+		assert.Equal(t, time.Duration(0.999*float64(4*time.Second)), b.Next())
+	})
+}
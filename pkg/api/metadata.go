@@ -57,6 +57,10 @@ func (m Metadata) Location() string {
 	return http.Header(m).Get("Location")
 }
 
+func (m Metadata) ETag() string {
+	return http.Header(m).Get("ETag")
+}
+
 func (m Metadata) LastModified() time.Time {
 	value, _ := http.ParseTime(http.Header(m).Get("Last-Modified"))
 	return value
@@ -74,6 +78,16 @@ func (m Metadata) Link(rel string) string {
 	return ""
 }
 
+// NextPageURL returns the location of the next page of a paginated list, if any.
+func (m Metadata) NextPageURL() string {
+	return m.Link(RelationNext)
+}
+
+// PrevPageURL returns the location of the previous page of a paginated list, if any.
+func (m Metadata) PrevPageURL() string {
+	return m.Link(RelationPrev)
+}
+
 func splitLink(value string) (rel, link string) {
 	for _, l := range strings.Split(value, ";") {
 		l = strings.Trim(l, " ")
@@ -56,6 +56,11 @@ func TestMetadata(t *testing.T) {
 	// Combined links, canonical relations
 	assert.Equal(t, "/list?offset=0", md.Link(RelationPrev))
 	assert.Equal(t, "/list?offset=10", md.Link(RelationNext))
+
+	// Pagination accessors
+	assert.Equal(t, "/list?offset=0", md.PrevPageURL())
+	assert.Equal(t, "/list?offset=10", md.NextPageURL())
+	assert.Equal(t, "", Metadata{}.NextPageURL())
 }
 
 func TestJsonMetadata_UnmarshalJSON(t *testing.T) {
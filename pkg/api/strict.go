@@ -0,0 +1,140 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// checkUnknownFields verifies every field of the JSON document is known to the type
+// of the supplied value. Unlike `json.Decoder.DisallowUnknownFields`, the check is
+// independent of the decoding so it also applies to types which implement their own
+// JSON unmarshalling (strictness cannot be passed through `json.Unmarshaler`).
+func checkUnknownFields(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return err
+	}
+	return checkFields(doc, reflect.TypeOf(v))
+}
+
+// checkFields recursively compares a generic JSON value to a type.
+func checkFields(doc interface{}, t reflect.Type) error {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil {
+		return nil
+	}
+
+	switch doc := doc.(type) {
+	case map[string]interface{}:
+		switch t.Kind() {
+		case reflect.Struct:
+			fields := jsonFields(t)
+			for key, value := range doc {
+				ft, ok := lookupField(fields, key)
+				if !ok {
+					return fmt.Errorf("json: unknown field %q", key)
+				}
+				if err := checkFields(value, ft); err != nil {
+					return err
+				}
+			}
+		case reflect.Map:
+			for _, value := range doc {
+				if err := checkFields(value, t.Elem()); err != nil {
+					return err
+				}
+			}
+		}
+	case []interface{}:
+		switch t.Kind() {
+		case reflect.Slice, reflect.Array:
+			for _, value := range doc {
+				if err := checkFields(value, t.Elem()); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// jsonFields returns the JSON field names of a struct type, including the fields
+// promoted from embedded structs. Shallower fields take precedence.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	var embedded []reflect.Type
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		name := strings.Split(tag, ",")[0]
+
+		switch {
+		case tag == "-":
+			// Metadata is populated from the response headers or the "_metadata" field
+			if f.Type == reflect.TypeOf(Metadata{}) {
+				fields["_metadata"] = nil
+			}
+		case f.Anonymous && name == "":
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				embedded = append(embedded, ft)
+			} else if f.IsExported() {
+				fields[ft.Name()] = f.Type
+			}
+		case !f.IsExported():
+		case name == "":
+			fields[f.Name] = f.Type
+		default:
+			fields[name] = f.Type
+		}
+	}
+
+	for _, et := range embedded {
+		for name, ft := range jsonFields(et) {
+			if _, ok := fields[name]; !ok {
+				fields[name] = ft
+			}
+		}
+	}
+	return fields
+}
+
+// lookupField finds a field using the same case-insensitive matching as `encoding/json`.
+func lookupField(fields map[string]reflect.Type, key string) (reflect.Type, bool) {
+	if ft, ok := fields[key]; ok {
+		return ft, true
+	}
+	for name, ft := range fields {
+		if strings.EqualFold(name, key) {
+			return ft, true
+		}
+	}
+	return nil, false
+}
@@ -47,3 +47,23 @@ func (d Duration) MarshalJSON() ([]byte, error) {
 func (d Duration) String() string {
 	return time.Duration(d).String()
 }
+
+// Clock is used to obtain the current time, allowing time dependent code to be tested.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+}
+
+// SystemClock is the clock backed by the system time.
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+// Now returns the current system time.
+func (systemClock) Now() time.Time { return time.Now() }
+
+// FixedClock is a clock which always returns the same time.
+type FixedClock time.Time
+
+// Now returns the fixed time.
+func (c FixedClock) Now() time.Time { return time.Time(c) }
@@ -0,0 +1,111 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// expiryDelta is how early a token is considered expired, it matches the oauth2 package.
+const expiryDelta = 10 * time.Second
+
+// refreshableTokenSource caches tokens from the underlying source until they
+// expire or are explicitly invalidated.
+type refreshableTokenSource struct {
+	src   oauth2.TokenSource
+	clock Clock
+	mu    sync.Mutex
+	tok   *oauth2.Token
+}
+
+// Token returns the cached token, obtaining a new token if necessary.
+func (s *refreshableTokenSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.tok != nil && s.tok.AccessToken != "" && !tokenExpired(s.tok, s.clock.Now()) {
+		return s.tok, nil
+	}
+
+	tok, err := s.src.Token()
+	if err != nil {
+		return nil, err
+	}
+	s.tok = tok
+	return tok, nil
+}
+
+// Invalidate discards the cached token so the next call to `Token` obtains a new token.
+func (s *refreshableTokenSource) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tok = nil
+}
+
+// authorizationTransport only authorizes requests with the same scheme and host as the
+// client's address, requests sent elsewhere (e.g. to an endpoint override for a different
+// host) must be authorized by the underlying transport, if at all.
+type authorizationTransport struct {
+	oauth2.Transport
+	Server *url.URL
+}
+
+// RoundTrip authorizes the request only if it is sent to the client's server.
+func (t *authorizationTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if sameHost(req.URL, t.Server) {
+		return t.Transport.RoundTrip(req)
+	}
+
+	if t.Base == nil {
+		return http.DefaultTransport.RoundTrip(req)
+	}
+	return t.Base.RoundTrip(req)
+}
+
+// sameHost checks if the URLs have the same scheme and host.
+func sameHost(u, v *url.URL) bool {
+	return strings.EqualFold(u.Scheme, v.Scheme) && strings.EqualFold(u.Host, v.Host)
+}
+
+// tokenExpired checks the expiry of a token relative to the supplied time. If the token
+// does not carry an explicit expiry, the "exp" claim of the access token is checked instead,
+// allowing the token to be refreshed before the server rejects it. Tokens without an explicit
+// expiry which are not JWTs never expire.
+func tokenExpired(tok *oauth2.Token, now time.Time) bool {
+	if !tok.Expiry.IsZero() {
+		return !tok.Expiry.Add(-expiryDelta).After(now)
+	}
+
+	t, err := jwt.ParseSigned(tok.AccessToken)
+	if err != nil {
+		return false
+	}
+
+	claims := jwt.Claims{}
+	if err := t.UnsafeClaimsWithoutVerification(&claims); err != nil || claims.Expiry == nil {
+		return false
+	}
+	return !claims.Expiry.Time().Add(-expiryDelta).After(now)
+}
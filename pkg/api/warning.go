@@ -0,0 +1,124 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// WarningHandler is invoked for each warning returned by the server.
+type WarningHandler func(Warning)
+
+// Warning is a warning returned by the server (e.g. indicating a deprecated API).
+type Warning struct {
+	// The three digit warning code, e.g. 299 for a miscellaneous persistent warning.
+	Code int `json:"code"`
+	// The agent which added the warning, typically "-".
+	Agent string `json:"agent,omitempty"`
+	// The warning message.
+	Text string `json:"text"`
+}
+
+// String returns the warning message.
+func (w Warning) String() string {
+	return w.Text
+}
+
+// Warnings returns the warnings included in the metadata.
+func (m Metadata) Warnings() []Warning {
+	return ParseWarnings(http.Header(m))
+}
+
+// ParseWarnings returns all of the `Warning` header values (RFC 7234) from the
+// supplied headers. Malformed values are ignored.
+func ParseWarnings(h http.Header) []Warning {
+	var warnings []Warning
+	for _, v := range h.Values("Warning") {
+		for v != "" {
+			var w Warning
+			var ok bool
+			w, v, ok = parseWarning(v)
+			if !ok {
+				break
+			}
+			warnings = append(warnings, w)
+		}
+	}
+	return warnings
+}
+
+// parseWarning parses a single `warn-code SP warn-agent SP warn-text [SP warn-date]`
+// value, returning the remainder of a comma separated list of values.
+func parseWarning(v string) (Warning, string, bool) {
+	w := Warning{}
+
+	// warn-code
+	v = strings.TrimLeft(v, " ,")
+	code, rest, ok := strings.Cut(v, " ")
+	if !ok || len(code) != 3 {
+		return w, "", false
+	}
+	var err error
+	if w.Code, err = strconv.Atoi(code); err != nil {
+		return w, "", false
+	}
+
+	// warn-agent
+	w.Agent, rest, ok = strings.Cut(strings.TrimLeft(rest, " "), " ")
+	if !ok {
+		return w, "", false
+	}
+
+	// warn-text (a quoted string)
+	rest = strings.TrimLeft(rest, " ")
+	if !strings.HasPrefix(rest, `"`) {
+		return w, "", false
+	}
+	var text strings.Builder
+	i := 1
+	for ; i < len(rest); i++ {
+		c := rest[i]
+		if c == '\\' && i+1 < len(rest) {
+			i++
+			text.WriteByte(rest[i])
+			continue
+		}
+		if c == '"' {
+			break
+		}
+		text.WriteByte(c)
+	}
+	if i >= len(rest) {
+		return w, "", false
+	}
+	w.Text = text.String()
+
+	// Skip the optional warn-date, through to the next value
+	rest = rest[i+1:]
+	if strings.HasPrefix(strings.TrimLeft(rest, " "), `"`) {
+		rest = strings.TrimLeft(rest, " ")
+		if end := strings.Index(rest[1:], `"`); end >= 0 {
+			rest = rest[end+2:]
+		}
+	}
+	if _, next, ok := strings.Cut(rest, ","); ok {
+		return w, next, true
+	}
+	return w, "", true
+}
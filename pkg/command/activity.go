@@ -45,7 +45,7 @@ func NewGetActivityCommand(cfg Config, p Printer) *cobra.Command {
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		ctx, out := cmd.Context(), cmd.OutOrStdout()
-		client, err := api.NewClient(cfg.Address(), nil)
+		client, err := newClient(cmd.Context(), cfg)
 		if err != nil {
 			return err
 		}
@@ -116,7 +116,11 @@ func NewWatchActivityCommand(cfg Config) *cobra.Command {
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		ctx, out := cmd.Context(), cmd.OutOrStdout()
-		client, err := api.NewClient(cfg.Address(), nil)
+		if err := applyPreferences(cmd, cfg); err != nil {
+			return err
+		}
+
+		client, err := newClient(cmd.Context(), cfg)
 		if err != nil {
 			return err
 		}
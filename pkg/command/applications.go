@@ -46,7 +46,7 @@ func NewCreateApplicationCommand(cfg Config, p Printer) *cobra.Command {
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		ctx, out := cmd.Context(), cmd.OutOrStdout()
-		client, err := api.NewClient(cfg.Address(), nil)
+		client, err := newClient(cmd.Context(), cfg)
 		if err != nil {
 			return err
 		}
@@ -112,7 +112,7 @@ func NewEditApplicationCommand(cfg Config, p Printer) *cobra.Command {
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		ctx, out := cmd.Context(), cmd.OutOrStdout()
-		client, err := api.NewClient(cfg.Address(), nil)
+		client, err := newClient(cmd.Context(), cfg)
 		if err != nil {
 			return err
 		}
@@ -179,7 +179,7 @@ func NewEnableApplicationRecommendationsCommand(cfg Config, p Printer) *cobra.Co
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		ctx, out := cmd.Context(), cmd.OutOrStdout()
-		client, err := api.NewClient(cfg.Address(), nil)
+		client, err := newClient(cmd.Context(), cfg)
 		if err != nil {
 			return err
 		}
@@ -236,7 +236,7 @@ func NewDisableApplicationRecommendationsCommand(cfg Config, p Printer) *cobra.C
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		ctx, out := cmd.Context(), cmd.OutOrStdout()
-		client, err := api.NewClient(cfg.Address(), nil)
+		client, err := newClient(cmd.Context(), cfg)
 		if err != nil {
 			return err
 		}
@@ -293,7 +293,11 @@ func NewGetApplicationsCommand(cfg Config, p Printer) *cobra.Command {
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		ctx, out := cmd.Context(), cmd.OutOrStdout()
-		client, err := api.NewClient(cfg.Address(), nil)
+		if err := applyPreferences(cmd, cfg); err != nil {
+			return err
+		}
+
+		client, err := newClient(cmd.Context(), cfg)
 		if err != nil {
 			return err
 		}
@@ -386,7 +390,7 @@ func NewDeleteApplicationsCommand(cfg Config, p Printer) *cobra.Command {
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		ctx, out := cmd.Context(), cmd.OutOrStdout()
-		client, err := api.NewClient(cfg.Address(), nil)
+		client, err := newClient(cmd.Context(), cfg)
 		if err != nil {
 			return err
 		}
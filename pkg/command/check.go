@@ -0,0 +1,234 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/thestormforge/optimize-go/pkg/api"
+	applications "github.com/thestormforge/optimize-go/pkg/api/applications/v2"
+	experiments "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
+	"github.com/thestormforge/optimize-go/pkg/config"
+	"golang.org/x/oauth2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// checkError is returned by a check step to describe how the failure can be fixed.
+type checkError struct {
+	err         error
+	remediation string
+}
+
+func (e *checkError) Error() string { return e.err.Error() }
+func (e *checkError) Unwrap() error { return e.err }
+
+// NewCheckCommand returns a command for verifying connectivity and authorization end-to-end.
+func NewCheckCommand(cfg Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:  "check",
+		Args: cobra.NoArgs,
+	}
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx, out := cmd.Context(), cmd.OutOrStdout()
+
+		// The steps share the token so each request uses the token being checked
+		var tok *oauth2.Token
+		client := func() (api.Client, error) {
+			var opts []api.ClientOption
+			if tok != nil {
+				opts = append(opts, api.WithTokenSource(oauth2.StaticTokenSource(tok)))
+			}
+			return api.NewClient(cfg.Address(), nil, opts...)
+		}
+
+		steps := []struct {
+			name string
+			run  func() (string, error)
+		}{
+			{
+				name: "configuration",
+				run:  func() (string, error) { return checkConfig(cfg) },
+			},
+			{
+				name: "server",
+				run: func() (string, error) {
+					c, err := client()
+					if err != nil {
+						return "", err
+					}
+					return checkServer(ctx, c)
+				},
+			},
+			{
+				name: "authorization",
+				run: func() (detail string, err error) {
+					tok, detail, err = checkToken(ctx, cfg)
+					return detail, err
+				},
+			},
+			{
+				name: "experiments API",
+				run: func() (string, error) {
+					c, err := client()
+					if err != nil {
+						return "", err
+					}
+					return checkEndpoint(experiments.NewAPI(c).CheckEndpoint(ctx))
+				},
+			},
+			{
+				name: "applications API",
+				run: func() (string, error) {
+					c, err := client()
+					if err != nil {
+						return "", err
+					}
+					return checkEndpoint(applications.NewAPI(c).CheckEndpoint(ctx))
+				},
+			},
+		}
+
+		var failed int
+		for i, step := range steps {
+			detail, err := step.run()
+			printCheck(out, step.name, detail, err)
+			if err != nil {
+				failed++
+
+				// Nothing else can work without a valid configuration
+				if i == 0 {
+					break
+				}
+			}
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("%d check(s) failed", failed)
+		}
+		return nil
+	}
+	return cmd
+}
+
+// printCheck writes the result of a single check step.
+func printCheck(out io.Writer, name, detail string, err error) {
+	if err == nil {
+		_, _ = fmt.Fprintf(out, "PASS  %s: %s\n", name, detail)
+		return
+	}
+
+	_, _ = fmt.Fprintf(out, "FAIL  %s: %v\n", name, err)
+	if cerr, ok := err.(*checkError); ok && cerr.remediation != "" {
+		_, _ = fmt.Fprintf(out, "      %s\n", cerr.remediation)
+	}
+}
+
+// checkConfig verifies the server endpoints can be resolved.
+func checkConfig(cfg Config) (string, error) {
+	if c, ok := cfg.(*config.Config); ok {
+		if _, err := c.ResolveServer(); err != nil {
+			return "", &checkError{err: err, remediation: "Check the STORMFORGE_SERVER and STORMFORGE_ISSUER environment variables."}
+		}
+	}
+
+	if cfg.Address() == "" {
+		return "", &checkError{err: fmt.Errorf("missing server address"), remediation: "Set the STORMFORGE_SERVER environment variable."}
+	}
+	return fmt.Sprintf("using server %q", cfg.Address()), nil
+}
+
+// checkServer verifies the server can be reached, any response is acceptable.
+func checkServer(ctx context.Context, c api.Client) (string, error) {
+	req, err := http.NewRequest(http.MethodHead, c.URL("").String(), nil)
+	if err != nil {
+		return "", err
+	}
+
+	start := clock.Now()
+	if _, _, err := c.Do(ctx, req); err != nil {
+		return "", &checkError{err: err, remediation: "Check your network connection and any proxy settings."}
+	}
+	return fmt.Sprintf("responded in %s", clock.Now().Sub(start).Round(time.Millisecond)), nil
+}
+
+// checkToken verifies a token can be obtained and has not expired.
+func checkToken(ctx context.Context, cfg Config) (*oauth2.Token, string, error) {
+	tok, err := token(ctx, cfg)
+	if err != nil {
+		return nil, "", &checkError{err: err, remediation: "Set the STORMFORGE_CLIENT_ID and STORMFORGE_CLIENT_SECRET (or STORMFORGE_TOKEN) environment variables."}
+	}
+
+	if acfg, ok := cfg.(interface{ CheckAudience(*oauth2.Token) error }); ok {
+		if err := acfg.CheckAudience(tok); err != nil {
+			return tok, "", &checkError{err: err, remediation: "Make sure the credentials are for the configured server."}
+		}
+	}
+
+	// Non-JWT tokens cannot be inspected any further
+	accessToken, err := jwt.ParseSigned(tok.AccessToken)
+	if err != nil {
+		return tok, "obtained an opaque token", nil
+	}
+	claims := struct {
+		jwt.Claims
+		Scope string `json:"scope"`
+	}{}
+	if err := accessToken.UnsafeClaimsWithoutVerification(&claims); err != nil {
+		return tok, "", err
+	}
+
+	expiry := tok.Expiry
+	if claims.Expiry != nil {
+		expiry = claims.Expiry.Time()
+	}
+	if !expiry.IsZero() && expiry.Before(clock.Now()) {
+		return tok, "", &checkError{err: fmt.Errorf("token expired at %s", expiry.Format(time.RFC3339)), remediation: "Obtain a new token."}
+	}
+
+	if c, ok := cfg.(*config.Config); ok && len(c.Scopes) > 0 {
+		missing, err := config.MissingScopes(tok, c.Scopes...)
+		if err != nil {
+			return tok, "", err
+		}
+		if len(missing) > 0 {
+			return tok, "", &checkError{err: fmt.Errorf("token is missing scopes: %s", strings.Join(missing, ", ")), remediation: "Request the missing scopes when obtaining the token."}
+		}
+	}
+
+	detail := fmt.Sprintf("token for %q", claims.Subject)
+	if !expiry.IsZero() {
+		detail += fmt.Sprintf(", expires in %s", time.Until(expiry).Round(time.Second))
+	}
+	return tok, detail, nil
+}
+
+// checkEndpoint interprets the result of an API endpoint check.
+func checkEndpoint(_ api.Metadata, err error) (string, error) {
+	if api.IsUnauthorized(err) {
+		return "", &checkError{err: err, remediation: "Verify the credentials are valid and have access to this API."}
+	} else if err != nil {
+		return "", &checkError{err: err, remediation: "Check the server address and try again later."}
+	}
+	return "available", nil
+}
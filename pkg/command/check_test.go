@@ -0,0 +1,124 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thestormforge/optimize-go/pkg/api"
+	"golang.org/x/oauth2"
+)
+
+// tokenConfig is a test configuration which can produce a token.
+type tokenConfig struct {
+	testConfig
+	token string
+}
+
+func (c tokenConfig) TokenSource(context.Context) oauth2.TokenSource {
+	return oauth2.StaticTokenSource(&oauth2.Token{AccessToken: c.token})
+}
+
+// testJWT returns an unsigned JWT with the supplied claims.
+func testJWT(claims string) string {
+	enc := base64.RawURLEncoding.EncodeToString
+	return enc([]byte(`{"alg":"HS256"}`)) + "." + enc([]byte(claims)) + "." + enc([]byte("sig"))
+}
+
+func TestCheckCommand(t *testing.T) {
+	now := time.Date(2022, time.March, 1, 12, 0, 0, 0, time.UTC)
+	defer func(c api.Clock) { clock = c }(clock)
+	clock = api.FixedClock(now)
+
+	exp := now.Add(time.Hour).Unix()
+	valid := testJWT(fmt.Sprintf(`{"sub": "valid", "exp": %d}`, exp))
+	revoked := testJWT(fmt.Sprintf(`{"sub": "revoked", "exp": %d}`, exp))
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" && r.Header.Get("Authorization") != "Bearer "+valid {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	cases := []struct {
+		desc     string
+		token    string
+		err      bool
+		contains []string
+	}{
+		{
+			desc:  "all pass",
+			token: valid,
+			contains: []string{
+				"PASS  configuration:",
+				"PASS  server:",
+				`PASS  authorization: token for "valid"`,
+				"PASS  experiments API: available",
+				"PASS  applications API: available",
+			},
+		},
+		{
+			desc:  "failing auth",
+			token: revoked,
+			err:   true,
+			contains: []string{
+				"PASS  server:",
+				`PASS  authorization: token for "revoked"`,
+				"FAIL  experiments API: unauthorized\n      Verify the credentials",
+				"FAIL  applications API: unauthorized\n      Verify the credentials",
+			},
+		},
+		{
+			desc:  "expired token",
+			token: testJWT(fmt.Sprintf(`{"sub": "expired", "exp": %d}`, now.Add(-time.Minute).Unix())),
+			err:   true,
+			contains: []string{
+				"FAIL  authorization: token expired at 2022-03-01T11:59:00Z\n      Obtain a new token.",
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := NewCheckCommand(tokenConfig{testConfig: testConfig(srv.URL + "/"), token: c.token})
+			cmd.SetOut(&out)
+			cmd.SetErr(io.Discard)
+			cmd.SetArgs([]string{})
+			err := cmd.ExecuteContext(context.Background())
+			if c.err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			for _, s := range c.contains {
+				assert.Contains(t, out.String(), s)
+			}
+		})
+	}
+}
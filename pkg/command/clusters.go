@@ -41,7 +41,7 @@ func NewEditClusterCommand(cfg Config, p Printer) *cobra.Command {
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		ctx, out := cmd.Context(), cmd.OutOrStdout()
-		client, err := api.NewClient(cfg.Address(), nil)
+		client, err := newClient(cmd.Context(), cfg)
 		if err != nil {
 			return err
 		}
@@ -91,7 +91,7 @@ func NewGetClustersCommand(cfg Config, p Printer) *cobra.Command {
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		ctx, out := cmd.Context(), cmd.OutOrStdout()
-		client, err := api.NewClient(cfg.Address(), nil)
+		client, err := newClient(cmd.Context(), cfg)
 		if err != nil {
 			return err
 		}
@@ -143,7 +143,7 @@ func NewDeleteClustersCommand(cfg Config, p Printer) *cobra.Command {
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		ctx, out := cmd.Context(), cmd.OutOrStdout()
-		client, err := api.NewClient(cfg.Address(), nil)
+		client, err := newClient(cmd.Context(), cfg)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,57 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/thestormforge/optimize-go/pkg/config"
+)
+
+// NewViewConfigCommand returns a command for viewing the current configuration.
+func NewViewConfigCommand(cfg Config, p Printer) *cobra.Command {
+	var (
+		resolved bool
+	)
+
+	cmd := &cobra.Command{
+		Use:  "view",
+		Args: cobra.NoArgs,
+	}
+
+	cmd.Flags().BoolVar(&resolved, "resolved", resolved, "show the fully resolved server endpoints")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		c, ok := cfg.(*config.Config)
+		if !ok {
+			return fmt.Errorf("unable to view configuration")
+		}
+
+		if !resolved {
+			return p.Fprint(out, c.Redacted())
+		}
+
+		rs, err := c.ResolveServer()
+		if err != nil {
+			return err
+		}
+		return p.Fprint(out, rs)
+	}
+	return cmd
+}
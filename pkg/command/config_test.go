@@ -0,0 +1,45 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thestormforge/optimize-go/pkg/config"
+)
+
+func TestViewConfigCommand_Resolved(t *testing.T) {
+	cfg := &config.Config{
+		Server:       "https://api.example.com/stormforge",
+		Issuer:       "https://auth.example.com/",
+		ClientID:     "abc",
+		ClientSecret: "s3cr3t",
+	}
+
+	var out bytes.Buffer
+	cmd := NewViewConfigCommand(cfg, &NDJSONPrinter{})
+	cmd.SetArgs([]string{"--resolved"})
+	cmd.SetOut(&out)
+	if assert.NoError(t, cmd.ExecuteContext(context.Background())) {
+		assert.Contains(t, out.String(), `"experiments_url":"https://api.example.com/stormforge/v1/experiments/"`)
+		assert.Contains(t, out.String(), `"token_url":"https://auth.example.com/oauth/token"`)
+		assert.NotContains(t, out.String(), "s3cr3t")
+	}
+}
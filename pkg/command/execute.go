@@ -0,0 +1,49 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+
+	"github.com/spf13/cobra"
+)
+
+// ExecuteContext runs the supplied command using a context that is canceled when
+// an interrupt signal is received, aborting any in-flight requests. Errors are
+// reported on the command's error stream; errors caused by the cancellation are
+// reported using a short message instead of the underlying request failure.
+func ExecuteContext(ctx context.Context, cmd *cobra.Command) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	cmd.SilenceErrors = true
+	err := cmd.ExecuteContext(ctx)
+	if err == nil {
+		return nil
+	}
+
+	if ctx.Err() != nil && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
+		cmd.PrintErrln("Interrupted.")
+		return ctx.Err()
+	}
+
+	cmd.PrintErrln("Error:", err.Error())
+	return err
+}
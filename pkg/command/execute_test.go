@@ -0,0 +1,81 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecuteContext(t *testing.T) {
+	started := make(chan struct{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("labelSelector") == "fail=true" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		// Block until the client gives up
+		started <- struct{}{}
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			<-started
+			cancel()
+		}()
+
+		var stderr bytes.Buffer
+		cmd := NewGetExperimentsCommand(testConfig(srv.URL+"/"), discardPrinter{})
+		cmd.SetArgs([]string{})
+		cmd.SetOut(io.Discard)
+		cmd.SetErr(&stderr)
+
+		start := time.Now()
+		err := ExecuteContext(ctx, cmd)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Less(t, time.Since(start), 2*time.Second)
+		assert.Equal(t, "Interrupted.\n", stderr.String())
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		var stderr bytes.Buffer
+		cmd := NewGetExperimentsCommand(testConfig(srv.URL+"/"), discardPrinter{})
+		cmd.SetArgs([]string{"--selector", "fail=true"})
+		cmd.SetOut(io.Discard)
+		cmd.SetErr(&stderr)
+
+		err := ExecuteContext(context.Background(), cmd)
+		assert.Error(t, err)
+		assert.Contains(t, stderr.String(), "Error: ")
+		assert.NotContains(t, stderr.String(), "Interrupted.")
+	})
+}
@@ -17,14 +17,104 @@ limitations under the License.
 package command
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"os"
 	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/thestormforge/optimize-go/pkg/api"
 	experiments "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
+	"sigs.k8s.io/yaml"
 )
 
+// NewCreateExperimentCommand returns a command for creating experiments.
+func NewCreateExperimentCommand(cfg Config, p Printer) *cobra.Command {
+	var (
+		filename string
+	)
+
+	cmd := &cobra.Command{
+		Use:     "experiment [NAME] -f FILE",
+		Aliases: []string{"exp"},
+		Args:    cobra.MaximumNArgs(1),
+	}
+
+	cmd.Flags().StringVarP(&filename, "filename", "f", "", "`file` containing the experiment definition (YAML or JSON), use - for stdin")
+	_ = cmd.MarkFlagRequired("filename")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx, out := cmd.Context(), cmd.OutOrStdout()
+
+		// Read the experiment definition
+		var data []byte
+		var err error
+		if filename == "-" {
+			data, err = io.ReadAll(cmd.InOrStdin())
+		} else {
+			data, err = os.ReadFile(filename)
+		}
+		if err != nil {
+			return err
+		}
+
+		data, err = yaml.YAMLToJSON(data)
+		if err != nil {
+			return err
+		}
+
+		exp := experiments.Experiment{}
+		if err := json.Unmarshal(data, &exp); err != nil {
+			return err
+		}
+
+		// The name is not part of the experiment body, allow it to come from the file
+		spec := struct {
+			Name experiments.ExperimentName `json:"name"`
+		}{}
+		if err := json.Unmarshal(data, &spec); err != nil {
+			return err
+		}
+		if len(args) > 0 {
+			spec.Name = experiments.ExperimentName(args[0])
+		}
+		if spec.Name == "" {
+			return fmt.Errorf("experiment name is required")
+		}
+
+		client, err := newClient(cmd.Context(), cfg)
+		if err != nil {
+			return err
+		}
+
+		expAPI := experiments.NewAPI(client)
+
+		created, err := expAPI.CreateExperimentByName(ctx, spec.Name, exp)
+		if err != nil {
+			var eerr *api.Error
+			if errors.As(err, &eerr) {
+				switch eerr.Type {
+				case experiments.ErrExperimentNameConflict:
+					return fmt.Errorf("experiment %q already exists: %w", spec.Name, err)
+				case experiments.ErrExperimentNameInvalid:
+					return fmt.Errorf("invalid experiment name %q: %w", spec.Name, err)
+				case experiments.ErrExperimentInvalid:
+					return fmt.Errorf("invalid experiment %q: %w", spec.Name, err)
+				}
+			}
+			return err
+		}
+
+		if created.Name == "" {
+			created.Name = spec.Name
+		}
+		return p.Fprint(out, NewExperimentRow(&experiments.ExperimentItem{Experiment: created}))
+	}
+	return cmd
+}
+
 // NewEditExperimentCommand returns a command for editing an experiment.
 func NewEditExperimentCommand(cfg Config, p Printer) *cobra.Command {
 	var (
@@ -42,7 +132,7 @@ func NewEditExperimentCommand(cfg Config, p Printer) *cobra.Command {
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		ctx, out := cmd.Context(), cmd.OutOrStdout()
-		client, err := api.NewClient(cfg.Address(), nil)
+		client, err := newClient(cmd.Context(), cfg)
 		if err != nil {
 			return err
 		}
@@ -74,6 +164,7 @@ func NewEditExperimentCommand(cfg Config, p Printer) *cobra.Command {
 func NewGetExperimentsCommand(cfg Config, p Printer) *cobra.Command {
 	var (
 		batchSize int
+		limit     int
 		selector  string
 		sortBy    string
 	)
@@ -85,32 +176,88 @@ func NewGetExperimentsCommand(cfg Config, p Printer) *cobra.Command {
 	}
 
 	cmd.Flags().IntVar(&batchSize, "batch-size", batchSize, "fetch large lists in chu`n`ks rather then all at once")
+	cmd.Flags().IntVar(&limit, "limit", limit, "stop after `n` experiments")
 	cmd.Flags().StringVarP(&selector, "selector", "l", selector, "selector (label `query`) to filter on")
 	cmd.Flags().StringVar(&sortBy, "sort-by", sortBy, "sort using `column` name")
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		ctx, out := cmd.Context(), cmd.OutOrStdout()
-		client, err := api.NewClient(cfg.Address(), nil)
+		if err := applyPreferences(cmd, cfg); err != nil {
+			return err
+		}
+
+		client, err := newClient(cmd.Context(), cfg)
 		if err != nil {
 			return err
 		}
 
+		progress, done := newProgress(cmd, "experiments")
 		l := experiments.Lister{
 			API:       experiments.NewAPI(client),
 			BatchSize: batchSize,
+			MaxItems:  limit,
+			Progress:  progress,
 		}
 
 		result := &ExperimentOutput{Items: make([]ExperimentRow, 0, len(args))}
 		if len(args) > 0 {
-			if err := l.ForEachNamedExperiment(ctx, args, false, result.Add); err != nil {
-				return err
-			}
+			err = l.ForEachNamedExperiment(ctx, args, false, result.Add)
 		} else {
 			q := experiments.ExperimentListQuery{}
 			q.SetLabelSelector(parseLabelSelector(selector))
-			if err := l.ForEachExperiment(ctx, q, result.Add); err != nil {
-				return err
+			err = l.ForEachExperiment(ctx, q, result.Add)
+		}
+		done()
+		if err != nil {
+			return err
+		}
+
+		if err := result.SortBy(sortBy); err != nil {
+			return err
+		}
+
+		return p.Fprint(out, result)
+	}
+	return cmd
+}
+
+// NewGetParametersCommand returns a command for getting the parameters of an experiment.
+func NewGetParametersCommand(cfg Config, p Printer) *cobra.Command {
+	var (
+		sortBy string
+	)
+
+	cmd := &cobra.Command{
+		Use:               "parameters EXPERIMENT",
+		Aliases:           []string{"parameter", "params", "param"},
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: validExperimentArgs(cfg),
+	}
+
+	cmd.Flags().StringVar(&sortBy, "sort-by", sortBy, "sort using `column` name")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx, out := cmd.Context(), cmd.OutOrStdout()
+		client, err := newClient(cmd.Context(), cfg)
+		if err != nil {
+			return err
+		}
+
+		l := experiments.Lister{
+			API: experiments.NewAPI(client),
+		}
+
+		result := &ParameterOutput{}
+		if err := l.ForEachNamedExperiment(ctx, args, false, func(item *experiments.ExperimentItem) error {
+			for i := range item.Parameters {
+				if err := result.Add(&item.Parameters[i]); err != nil {
+					return err
+				}
 			}
+			result.SetConstraints(item.Constraints)
+			return nil
+		}); err != nil {
+			return err
 		}
 
 		if err := result.SortBy(sortBy); err != nil {
@@ -126,6 +273,7 @@ func NewGetExperimentsCommand(cfg Config, p Printer) *cobra.Command {
 func NewDeleteExperimentsCommand(cfg Config, p Printer) *cobra.Command {
 	var (
 		ignoreNotFound bool
+		cascade        bool
 	)
 
 	cmd := &cobra.Command{
@@ -135,10 +283,11 @@ func NewDeleteExperimentsCommand(cfg Config, p Printer) *cobra.Command {
 	}
 
 	cmd.Flags().BoolVar(&ignoreNotFound, "ignore-not-found", ignoreNotFound, "treat not found errors as successful deletes")
+	cmd.Flags().BoolVar(&cascade, "cascade", cascade, "abandon active trials before deleting the experiment")
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		ctx, out := cmd.Context(), cmd.OutOrStdout()
-		client, err := api.NewClient(cfg.Address(), nil)
+		client, err := newClient(cmd.Context(), cfg)
 		if err != nil {
 			return err
 		}
@@ -153,6 +302,21 @@ func NewDeleteExperimentsCommand(cfg Config, p Printer) *cobra.Command {
 				return fmt.Errorf("malformed response, missing self link")
 			}
 
+			// Abandon any active trials so they are not left running
+			if cascade {
+				q := experiments.TrialListQuery{}
+				q.SetStatus(experiments.TrialActive)
+				if err := l.ForEachTrial(ctx, &item.Experiment, q, func(trial *experiments.TrialItem) error {
+					trialURL := trial.Link(api.RelationSelf)
+					if trialURL == "" {
+						return fmt.Errorf("malformed response, missing self link")
+					}
+					return l.API.AbandonRunningTrial(ctx, trialURL)
+				}); err != nil {
+					return err
+				}
+			}
+
 			if err := l.API.DeleteExperiment(ctx, selfURL); err != nil {
 				return err
 			}
@@ -163,6 +327,47 @@ func NewDeleteExperimentsCommand(cfg Config, p Printer) *cobra.Command {
 	return cmd
 }
 
+// NewLabelExperimentsCommand returns a command for labeling experiments.
+func NewLabelExperimentsCommand(cfg Config, p Printer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "experiments NAME ... KEY=VALUE ... | KEY- ...",
+		Aliases:           []string{"experiment", "exps", "exp"},
+		Args:              cobra.MinimumNArgs(2),
+		ValidArgsFunction: validExperimentArgs(cfg),
+	}
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx, out := cmd.Context(), cmd.OutOrStdout()
+		names, labels, err := argsToNamesAndLabels(args)
+		if err != nil {
+			return err
+		}
+
+		client, err := newClient(cmd.Context(), cfg)
+		if err != nil {
+			return err
+		}
+
+		l := experiments.Lister{
+			API: experiments.NewAPI(client),
+		}
+
+		return l.ForEachNamedExperiment(ctx, names, false, func(item *experiments.ExperimentItem) error {
+			labelsURL := item.Link(api.RelationLabels)
+			if labelsURL == "" {
+				return fmt.Errorf("malformed response, missing labels link")
+			}
+
+			if err := l.API.LabelExperiment(ctx, labelsURL, experiments.ExperimentLabels{Labels: labels}); err != nil {
+				return err
+			}
+
+			return p.Fprint(out, NewExperimentRow(item))
+		})
+	}
+	return cmd
+}
+
 func validExperimentArgs(cfg Config) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
 	return validArgs(cfg, func(l *completionLister, toComplete string) (completions []string, directive cobra.ShellCompDirective) {
 		directive |= cobra.ShellCompDirectiveNoFileComp
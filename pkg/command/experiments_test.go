@@ -0,0 +1,410 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// capturePrinter is a printer that records everything it is asked to print.
+type capturePrinter struct {
+	objs []interface{}
+}
+
+func (p *capturePrinter) Fprint(_ io.Writer, obj interface{}) error {
+	p.objs = append(p.objs, obj)
+	return nil
+}
+
+func TestCreateExperimentCommand(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		exp := map[string]interface{}{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&exp))
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/experiments/fixture":
+			assert.Equal(t, "Fixture", exp["displayName"])
+			assert.Len(t, exp["metrics"], 2)
+			assert.NotContains(t, exp, "name")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = fmt.Fprint(w, `{"displayName": "Fixture"}`)
+		case "/v1/experiments/invalid":
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = fmt.Fprint(w, `{"error": "parameter bounds are invalid"}`)
+		case "/v1/experiments/conflict":
+			w.WriteHeader(http.StatusConflict)
+			_, _ = fmt.Fprint(w, `{"error": "name is already in use"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	fixture, err := os.ReadFile("testdata/experiment.yaml")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	cases := []struct {
+		desc  string
+		args  []string
+		stdin string
+		err   string
+	}{
+		{
+			desc: "file",
+			args: []string{"-f", "testdata/experiment.yaml"},
+		},
+		{
+			desc:  "stdin",
+			args:  []string{"-f", "-"},
+			stdin: string(fixture),
+		},
+		{
+			desc:  "JSON",
+			args:  []string{"fixture", "-f", "-"},
+			stdin: `{"displayName": "Fixture", "metrics": [{"name": "cost"}, {"name": "throughput"}], "parameters": []}`,
+		},
+		{
+			desc: "invalid",
+			args: []string{"invalid", "-f", "testdata/experiment.yaml"},
+			err:  `invalid experiment "invalid": parameter bounds are invalid`,
+		},
+		{
+			desc: "conflict",
+			args: []string{"conflict", "-f", "testdata/experiment.yaml"},
+			err:  `experiment "conflict" already exists: name is already in use`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			p := &capturePrinter{}
+			cmd := NewCreateExperimentCommand(testConfig(srv.URL+"/"), p)
+			cmd.SetArgs(c.args)
+			cmd.SetIn(strings.NewReader(c.stdin))
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			err := cmd.ExecuteContext(context.Background())
+			if c.err != "" {
+				assert.EqualError(t, err, c.err)
+				return
+			}
+			if assert.NoError(t, err) && assert.Len(t, p.objs, 1) {
+				row := p.objs[0].(*ExperimentRow)
+				assert.Equal(t, "fixture", row.Name)
+				assert.Equal(t, "Fixture", row.DisplayName)
+			}
+		})
+	}
+}
+
+func TestGetExperimentsCommand(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/experiments/", r.URL.Path)
+		assert.Equal(t, "team=a", r.URL.Query().Get("labelSelector"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"experiments": [
+  {"displayName": "One", "observations": 10, "labels": {"team": "a"}, "_metadata": {"Link": ["</v1/experiments/one>; rel=self"]}},
+  {"displayName": "Two", "observations": 20, "labels": {"team": "a"}, "_metadata": {"Link": ["</v1/experiments/two>; rel=self"]}}
+]}`)
+	}))
+	defer srv.Close()
+
+	p := &capturePrinter{}
+	cmd := NewGetExperimentsCommand(testConfig(srv.URL+"/"), p)
+	cmd.SetArgs([]string{"--selector", "team=a", "--sort-by", "observations"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	if !assert.NoError(t, cmd.ExecuteContext(context.Background())) {
+		return
+	}
+
+	if assert.Len(t, p.objs, 1) {
+		if result, ok := p.objs[0].(*ExperimentOutput); assert.True(t, ok) && assert.Len(t, result.Items, 2) {
+			assert.Equal(t, "one", result.Items[0].Name)
+			assert.Equal(t, "One", result.Items[0].DisplayName)
+			assert.Equal(t, int64(10), result.Items[0].Observations)
+			assert.Equal(t, "two", result.Items[1].Name)
+			assert.Equal(t, map[string]string{"team": "a"}, result.Items[1].Labels)
+		}
+	}
+}
+
+func TestDeleteExperimentsCommand(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+
+		switch r.Method + " " + r.URL.Path {
+		case "GET /v1/experiments/one":
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Add("Link", fmt.Sprintf("<%s/v1/experiments/one>; rel=self", srv.URL))
+			w.Header().Add("Link", fmt.Sprintf("<%s/v1/experiments/one/trials>; rel=https://stormforge.io/rel/trials", srv.URL))
+			_, _ = fmt.Fprint(w, `{}`)
+		case "GET /v1/experiments/one/trials":
+			assert.Equal(t, "active", r.URL.Query().Get("status"))
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = fmt.Fprintf(w, `{"trials": [{"number": 1, "_metadata": {"Link": ["<%s/v1/experiments/one/trials/1>; rel=self"]}}]}`, srv.URL)
+		case "DELETE /v1/experiments/one", "DELETE /v1/experiments/one/trials/1":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	cases := []struct {
+		desc     string
+		args     []string
+		err      bool
+		deleted  int
+		requests []string
+	}{
+		{
+			desc:    "delete",
+			args:    []string{"one"},
+			deleted: 1,
+			requests: []string{
+				"GET /v1/experiments/one",
+				"DELETE /v1/experiments/one",
+			},
+		},
+		{
+			desc:    "cascade",
+			args:    []string{"one", "--cascade"},
+			deleted: 1,
+			requests: []string{
+				"GET /v1/experiments/one",
+				"GET /v1/experiments/one/trials",
+				"DELETE /v1/experiments/one/trials/1",
+				"DELETE /v1/experiments/one",
+			},
+		},
+		{
+			desc: "not found",
+			args: []string{"missing", "one"},
+			err:  true,
+			requests: []string{
+				"GET /v1/experiments/missing",
+			},
+		},
+		{
+			desc:    "ignore not found",
+			args:    []string{"missing", "one", "--ignore-not-found"},
+			deleted: 1,
+			requests: []string{
+				"GET /v1/experiments/missing",
+				"GET /v1/experiments/one",
+				"DELETE /v1/experiments/one",
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			requests = nil
+			p := &capturePrinter{}
+			cmd := NewDeleteExperimentsCommand(testConfig(srv.URL+"/"), p)
+			cmd.SetArgs(c.args)
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			err := cmd.ExecuteContext(context.Background())
+			if c.err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Len(t, p.objs, c.deleted)
+			assert.Equal(t, c.requests, requests)
+		})
+	}
+}
+
+func TestLabelExperimentsCommand(t *testing.T) {
+	var labeled map[string]map[string]string
+
+	var srv *httptest.Server
+	mux := http.NewServeMux()
+	for _, name := range []string{"one", "two"} {
+		name := name
+		mux.HandleFunc("/v1/experiments/"+name, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Link", fmt.Sprintf("<%s/v1/experiments/%s/labels>; rel=https://stormforge.io/rel/labels", srv.URL, name))
+			_, _ = fmt.Fprint(w, `{}`)
+		})
+		mux.HandleFunc("/v1/experiments/"+name+"/labels", func(w http.ResponseWriter, r *http.Request) {
+			lbl := struct {
+				Labels map[string]string `json:"labels"`
+			}{}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&lbl))
+			labeled[name] = lbl.Labels
+			w.WriteHeader(http.StatusCreated)
+		})
+	}
+	srv = httptest.NewServer(mux)
+	defer srv.Close()
+
+	cases := []struct {
+		desc     string
+		args     []string
+		expected map[string]map[string]string
+	}{
+		{
+			desc: "set",
+			args: []string{"one", "two", "team=a", "best=true"},
+			expected: map[string]map[string]string{
+				"one": {"team": "a", "best": "true"},
+				"two": {"team": "a", "best": "true"},
+			},
+		},
+		{
+			desc: "remove",
+			args: []string{"one", "best-", "team=b"},
+			expected: map[string]map[string]string{
+				"one": {"team": "b", "best": ""},
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			labeled = make(map[string]map[string]string)
+			p := &capturePrinter{}
+			cmd := NewLabelExperimentsCommand(testConfig(srv.URL+"/"), p)
+			cmd.SetArgs(c.args)
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			if assert.NoError(t, cmd.ExecuteContext(context.Background())) {
+				assert.Equal(t, c.expected, labeled)
+				assert.Len(t, p.objs, len(c.expected))
+			}
+		})
+	}
+}
+
+func TestArgsToNamesAndLabels(t *testing.T) {
+	cases := []struct {
+		desc   string
+		args   []string
+		names  []string
+		labels map[string]string
+		err    bool
+	}{
+		{
+			desc:   "set and remove",
+			args:   []string{"one", "a=b", "c-"},
+			names:  []string{"one"},
+			labels: map[string]string{"a": "b", "c": ""},
+		},
+		{
+			desc:   "value with equals",
+			args:   []string{"one", "a=b=c"},
+			names:  []string{"one"},
+			labels: map[string]string{"a": "b=c"},
+		},
+		{
+			desc: "name after label",
+			args: []string{"one", "a=b", "two"},
+			err:  true,
+		},
+		{
+			desc: "missing labels",
+			args: []string{"one", "two"},
+			err:  true,
+		},
+		{
+			desc: "missing names",
+			args: []string{"a=b"},
+			err:  true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			names, labels, err := argsToNamesAndLabels(c.args)
+			if c.err {
+				assert.Error(t, err)
+			} else if assert.NoError(t, err) {
+				assert.Equal(t, c.names, names)
+				assert.Equal(t, c.labels, labels)
+			}
+		})
+	}
+}
+
+func TestGetParametersCommand(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/experiments/fixture", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"parameters": [
+  {"name": "gc", "type": "categorical", "values": ["G1", "Parallel"]},
+  {"name": "cpu", "type": "int", "bounds": {"min": 100, "max": 4000}},
+  {"name": "ratio", "type": "double", "bounds": {"min": 0.25, "max": 0.75}}
+], "constraints": [
+  {"name": "ratio-cpu", "constraintType": "sum", "isUpperBound": true, "bound": 1000, "parameters": [{"parameterName": "cpu", "weight": 1}, {"parameterName": "ratio", "weight": 100}]}
+]}`)
+	}))
+	defer srv.Close()
+
+	p := &capturePrinter{}
+	cmd := NewGetParametersCommand(testConfig(srv.URL+"/"), p)
+	cmd.SetArgs([]string{"fixture", "--sort-by", "name"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	if !assert.NoError(t, cmd.ExecuteContext(context.Background())) || !assert.Len(t, p.objs, 1) {
+		return
+	}
+
+	result, ok := p.objs[0].(*ParameterOutput)
+	if !assert.True(t, ok) || !assert.Len(t, result.Items, 3) {
+		return
+	}
+
+	cpu, gc, ratio := result.Items[0], result.Items[1], result.Items[2]
+	assert.Equal(t, []string{"cpu", "int", "100", "4000", ""}, []string{cpu.Name, cpu.Type, cpu.Min, cpu.Max, cpu.Values})
+	assert.Equal(t, []string{"gc", "categorical", "", "", "G1, Parallel"}, []string{gc.Name, gc.Type, gc.Min, gc.Max, gc.Values})
+	assert.Equal(t, []string{"ratio", "double", "0.25", "0.75", ""}, []string{ratio.Name, ratio.Type, ratio.Min, ratio.Max, ratio.Values})
+	assert.Equal(t, "cpu + 100*ratio <= 1000", cpu.Constraints)
+	assert.Equal(t, "", gc.Constraints)
+	assert.Equal(t, "cpu + 100*ratio <= 1000", ratio.Constraints)
+
+	var buf bytes.Buffer
+	if assert.NoError(t, (&JSONPrinter{}).Fprint(&buf, result)) {
+		assert.JSONEq(t, `{"items": [
+  {"name": "cpu", "type": "int", "bounds": {"min": 100, "max": 4000}},
+  {"name": "gc", "type": "categorical", "values": ["G1", "Parallel"]},
+  {"name": "ratio", "type": "double", "bounds": {"min": 0.25, "max": 0.75}}
+], "constraints": [
+  {"name": "ratio-cpu", "constraintType": "sum", "isUpperBound": true, "bound": 1000, "parameters": [{"parameterName": "cpu", "weight": 1}, {"parameterName": "ratio", "weight": 100}]}
+]}`, buf.String())
+	}
+}
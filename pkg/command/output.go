@@ -18,6 +18,7 @@ package command
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"sort"
@@ -26,11 +27,13 @@ import (
 	"time"
 
 	"github.com/dustin/go-humanize"
+	"github.com/thestormforge/optimize-go/pkg/api"
 	applications "github.com/thestormforge/optimize-go/pkg/api/applications/v2"
 	experiments "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/collate"
 	"golang.org/x/text/language"
+	"sigs.k8s.io/yaml"
 )
 
 // Printer is the interface required to render results.
@@ -39,6 +42,104 @@ type Printer interface {
 	Fprint(out io.Writer, obj interface{}) error
 }
 
+// NDJSONPrinter renders newline-delimited JSON, each list item is written as a
+// separate JSON object on its own line.
+type NDJSONPrinter struct{}
+
+// Fprint renders the object as one or more lines of JSON.
+func (p *NDJSONPrinter) Fprint(out io.Writer, obj interface{}) error {
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+
+	// Lists are written one item per line
+	if o, ok := obj.(Output); ok {
+		for i := 0; i < o.Len(); i++ {
+			if err := p.encode(out, enc, o.Item(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return p.encode(out, enc, obj)
+}
+
+// Streaming indicates list items can be rendered individually as they are produced.
+func (p *NDJSONPrinter) Streaming() bool {
+	return true
+}
+
+// encode writes a single line to the output, flushing it if possible.
+func (p *NDJSONPrinter) encode(out io.Writer, enc *json.Encoder, obj interface{}) error {
+	if err := enc.Encode(obj); err != nil {
+		return err
+	}
+	if f, ok := out.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// JSONPrinter renders indented JSON.
+type JSONPrinter struct{}
+
+// Fprint renders the object as a single JSON document.
+func (p *JSONPrinter) Fprint(out io.Writer, obj interface{}) error {
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(obj)
+}
+
+// YAMLPrinter renders YAML.
+type YAMLPrinter struct{}
+
+// Fprint renders the object as a single YAML document.
+func (p *YAMLPrinter) Fprint(out io.Writer, obj interface{}) error {
+	b, err := yaml.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(b)
+	return err
+}
+
+// NewPrinter returns a printer for the named output format, defaulting to JSON.
+func NewPrinter(format string) (Printer, error) {
+	switch format {
+	case "", "json":
+		return &JSONPrinter{}, nil
+	case "yaml":
+		return &YAMLPrinter{}, nil
+	case "ndjson":
+		return &NDJSONPrinter{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported output format %q", format)
+	}
+}
+
+// IsStreaming returns true if the printer can render list items individually, as
+// they are produced, instead of requiring the entire list up front.
+func IsStreaming(p Printer) bool {
+	sp, ok := p.(interface{ Streaming() bool })
+	return ok && sp.Streaming()
+}
+
+// OutputFormat returns the explicitly requested output format, falling back to
+// the preferred output format of the configuration (if it has one).
+func OutputFormat(cfg Config, format string) string {
+	if format != "" {
+		return format
+	}
+	if pref, ok := cfg.(interface{ OutputFormat() string }); ok {
+		return pref.OutputFormat()
+	}
+	return ""
+}
+
+// clock is used to obtain the current time, e.g. for humanized output.
+var clock = api.SystemClock
+
 // formatTime is a helper that returns empty strings for zero times and adds
 // support for a humanized format (if the layout is empty).
 func formatTime(t *time.Time, layout string) string {
@@ -46,14 +147,27 @@ func formatTime(t *time.Time, layout string) string {
 	case t == nil || t.IsZero():
 		return ""
 	case layout == "ago":
-		return humanize.Time(*t)
+		return humanize.RelTime(*t, clock.Now(), "ago", "from now")
 	case layout == "":
-		return strings.TrimSpace(humanize.RelTime(*t, time.Now(), "", ""))
+		return strings.TrimSpace(humanize.RelTime(*t, clock.Now(), "", ""))
 	default:
 		return t.Format(layout)
 	}
 }
 
+// formatNumber is a helper that rounds a number to the specified number of decimal
+// places (omitting trailing zeros), a negative precision uses the full value.
+func formatNumber(v float64, precision int) string {
+	s := strconv.FormatFloat(v, 'f', precision, 64)
+	if precision > 0 {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
+
 // NOTE: All the "*Row" structs have `json:"-"` for everything EXCEPT their
 // inline "*Item" field so when the row is marshalled as JSON it appears the
 // same as what the item would have been.
@@ -316,6 +430,83 @@ func (o *ExperimentOutput) Item(i int) Row { return &o.Items[i] }
 // SortBy sorts the output by the named value.
 func (o *ExperimentOutput) SortBy(key string) error { return SortBy(o, key) }
 
+// ParameterRow is a table row representation of an experiment parameter.
+type ParameterRow struct {
+	Name        string `table:"name" csv:"name" json:"-"`
+	Type        string `table:"type" csv:"type" json:"-"`
+	Min         string `table:"min" csv:"min" json:"-"`
+	Max         string `table:"max" csv:"max" json:"-"`
+	Values      string `table:"values" csv:"values" json:"-"`
+	Constraints string `table:"constraints,wide" csv:"constraints" json:"-"`
+
+	experiments.Parameter `table:"-" csv:"-"`
+}
+
+func NewParameterRow(item *experiments.Parameter) *ParameterRow {
+	row := &ParameterRow{
+		Name:   item.Name,
+		Type:   string(item.Type),
+		Values: strings.Join(item.Values, ", "),
+
+		Parameter: *item,
+	}
+	if item.Bounds != nil {
+		row.Min = item.Bounds.Min.String()
+		row.Max = item.Bounds.Max.String()
+	}
+	return row
+}
+
+func (r *ParameterRow) Lookup(key string) (interface{}, bool) {
+	switch SortByKey(key) {
+	case "name":
+		return r.Name, true
+	case "type":
+		return r.Type, true
+	default:
+		return nil, false
+	}
+}
+
+// ParameterOutput wraps an experiment's parameters for output.
+type ParameterOutput struct {
+	Items       []ParameterRow           `json:"items"`
+	Constraints []experiments.Constraint `json:"constraints,omitempty"`
+}
+
+// Add an experiment parameter to the output.
+func (o *ParameterOutput) Add(item *experiments.Parameter) error {
+	o.Items = append(o.Items, *NewParameterRow(item))
+	return nil
+}
+
+// SetConstraints records the experiment's constraints, each parameter row lists the
+// constraints it is involved in.
+func (o *ParameterOutput) SetConstraints(constraints []experiments.Constraint) {
+	o.Constraints = constraints
+	for i := range o.Items {
+		var exprs []string
+		for j := range constraints {
+			if constraints[j].References(o.Items[i].Name) {
+				exprs = append(exprs, constraints[j].String())
+			}
+		}
+		o.Items[i].Constraints = strings.Join(exprs, ", ")
+	}
+}
+
+// Len returns the number of items being output.
+func (o *ParameterOutput) Len() int { return len(o.Items) }
+
+// Swap exchanges the order of the two specified items.
+func (o *ParameterOutput) Swap(i, j int) { o.Items[i], o.Items[j] = o.Items[j], o.Items[i] }
+
+// Item returns the specified row value.
+func (o *ParameterOutput) Item(i int) Row { return &o.Items[i] }
+
+// SortBy sorts the output by the named value.
+func (o *ParameterOutput) SortBy(key string) error { return SortBy(o, key) }
+
 // TrialRow is a table row representation of a trial.
 type TrialRow struct {
 	Experiment     string            `table:"experiment,custom" csv:"experiment" json:"-"`
@@ -327,6 +518,11 @@ type TrialRow struct {
 	FailureReason  string            `table:"failure_reason,wide" csv:"failure_reason" json:"-"`
 	FailureMessage string            `table:"failure_message,wide" csv:"failure_message" json:"-"`
 	Labels         map[string]string `table:"labels,labels" csv:"label_,labels,flatten" json:"-"`
+	Best           string            `table:"best,wide" csv:"best" json:"-"`
+
+	// Typed representations of the assignments and values, keyed by parameter and metric name.
+	ParameterValues map[string]api.NumberOrString `table:"-" csv:"-" json:"parameterValues,omitempty"`
+	MetricValues    map[string]float64            `table:"-" csv:"-" json:"metricValues,omitempty"`
 
 	experiments.TrialItem `table:"-" csv:"-"`
 }
@@ -344,31 +540,61 @@ func NewTrialRow(item *experiments.TrialItem) *TrialRow {
 		name = fmt.Sprintf("%03d", item.Number)
 	}
 
-	assignments := make(map[string]string, len(item.Assignments))
+	parameterValues := make(map[string]api.NumberOrString, len(item.Assignments))
 	for i := range item.Assignments {
-		assignments[item.Assignments[i].ParameterName] = item.Assignments[i].Value.String()
+		parameterValues[item.Assignments[i].ParameterName] = item.Assignments[i].Value
 	}
 
-	values := make(map[string]string, len(item.Values))
+	metricValues := make(map[string]float64, len(item.Values))
 	for i := range item.Values {
-		values[item.Values[i].MetricName] = strconv.FormatFloat(item.Values[i].Value, 'f', -1, 64)
+		metricValues[item.Values[i].MetricName] = item.Values[i].Value
+	}
+
+	// The string representations used for display are derived from the typed values
+	assignments := make(map[string]string, len(parameterValues))
+	for k, v := range parameterValues {
+		assignments[k] = v.String()
+	}
+
+	values := make(map[string]string, len(metricValues))
+	for k, v := range metricValues {
+		values[k] = formatNumber(v, -1)
 	}
 
 	return &TrialRow{
-		Experiment:     experiment,
-		Name:           name,
-		Number:         item.Number,
-		Status:         cases.Title(language.English).String(string(item.Status)),
-		FailureReason:  item.FailureReason,
-		FailureMessage: item.FailureMessage,
-		Assignments:    assignments,
-		Values:         values,
-		Labels:         item.Labels,
+		Experiment:      experiment,
+		Name:            name,
+		Number:          item.Number,
+		Status:          cases.Title(language.English).String(string(item.Status)),
+		FailureReason:   item.FailureReason,
+		FailureMessage:  item.FailureMessage,
+		Assignments:     assignments,
+		Values:          values,
+		Labels:          item.Labels,
+		ParameterValues: parameterValues,
+		MetricValues:    metricValues,
 
 		TrialItem: *item,
 	}
 }
 
+// SetPrecision rounds the displayed numeric assignments and values to the specified
+// number of decimal places, a negative precision displays the full value. The typed
+// representations used for JSON output always retain full precision.
+func (r *TrialRow) SetPrecision(precision int) {
+	for k, v := range r.ParameterValues {
+		if v.IsString || precision < 0 {
+			r.Assignments[k] = v.String()
+			continue
+		}
+		r.Assignments[k] = formatNumber(v.Float64Value(), precision)
+	}
+
+	for k, v := range r.MetricValues {
+		r.Values[k] = formatNumber(v, precision)
+	}
+}
+
 func (r *TrialRow) Lookup(key string) (interface{}, bool) {
 	switch SortByKey(key) {
 	case "name":
@@ -405,6 +631,66 @@ func (o *TrialOutput) Item(i int) Row { return &o.Items[i] }
 // SortBy sorts the output by the named value.
 func (o *TrialOutput) SortBy(key string) error { return SortBy(o, key) }
 
+// MarkBest annotates the trials with the best value for each optimized metric of
+// their experiment. Each metric name is suffixed with an arrow indicating the
+// direction of optimization (i.e. "↓" when the metric is minimized).
+func (o *TrialOutput) MarkBest() {
+	// Group the trials by experiment, ignoring trials without experiment metadata
+	var names []experiments.ExperimentName
+	groups := make(map[experiments.ExperimentName][]int)
+	for i := range o.Items {
+		exp := o.Items[i].TrialItem.Experiment
+		if exp == nil {
+			continue
+		}
+		if _, ok := groups[exp.Name]; !ok {
+			names = append(names, exp.Name)
+		}
+		groups[exp.Name] = append(groups[exp.Name], i)
+	}
+
+	for _, name := range names {
+		idx := groups[name]
+		trials := make([]experiments.TrialItem, 0, len(idx))
+		for _, i := range idx {
+			trials = append(trials, o.Items[i].TrialItem)
+		}
+
+		exp := o.Items[idx[0]].TrialItem.Experiment
+		for _, m := range exp.Metrics {
+			if m.Optimize != nil && !*m.Optimize {
+				continue
+			}
+
+			best, ok := experiments.BestTrial(trials, m)
+			if !ok {
+				continue
+			}
+
+			for _, i := range idx {
+				if o.Items[i].Number == best.Number {
+					o.Items[i].Best = strings.TrimPrefix(o.Items[i].Best+","+m.Name+metricDirection(m), ",")
+				}
+			}
+		}
+	}
+}
+
+// metricDirection returns an arrow indicating the direction of optimization.
+func metricDirection(m experiments.Metric) string {
+	if m.Minimize {
+		return "↓"
+	}
+	return "↑"
+}
+
+// SetPrecision sets the display precision of every trial in the output.
+func (o *TrialOutput) SetPrecision(precision int) {
+	for i := range o.Items {
+		o.Items[i].SetPrecision(precision)
+	}
+}
+
 // ClusterRow is a table row representation of a cluster.
 type ClusterRow struct {
 	Name                   string `table:"name" csv:"name" json:"-"`
@@ -571,6 +857,8 @@ func SortBy(o Output, name string) error {
 			s.keys[i] = c.KeyFromString(buf, value)
 		case int:
 			s.keys[i] = c.KeyFromString(buf, strconv.Itoa(value))
+		case int64:
+			s.keys[i] = c.KeyFromString(buf, strconv.FormatInt(value, 10))
 		case *time.Time:
 			s.keys[i] = c.KeyFromString(buf, strconv.FormatInt(value.Unix(), 10))
 		default:
@@ -0,0 +1,257 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thestormforge/optimize-go/pkg/api"
+	experiments "github.com/thestormforge/optimize-go/pkg/api/experiments/v1alpha1"
+	"github.com/thestormforge/optimize-go/pkg/config"
+)
+
+func TestTrialOutput_MarshalJSON(t *testing.T) {
+	item := &experiments.TrialItem{
+		TrialAssignments: experiments.TrialAssignments{
+			Assignments: []experiments.Assignment{
+				{ParameterName: "cpu", Value: api.FromInt64(500)},
+				{ParameterName: "gc", Value: api.FromString("G1")},
+			},
+		},
+		TrialValues: experiments.TrialValues{
+			Values: []experiments.Value{
+				{MetricName: "cost", Value: 12.5},
+			},
+		},
+		Status: experiments.TrialCompleted,
+		Number: 1,
+	}
+
+	result := &TrialOutput{}
+	if err := result.Add(item); !assert.NoError(t, err) {
+		return
+	}
+
+	// Table rendering still uses the string representations
+	assert.Equal(t, map[string]string{"cpu": "500", "gc": "G1"}, result.Items[0].Assignments)
+	assert.Equal(t, map[string]string{"cost": "12.5"}, result.Items[0].Values)
+
+	data, err := json.Marshal(result)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var actual struct {
+		Items []struct {
+			ParameterValues map[string]interface{} `json:"parameterValues"`
+			MetricValues    map[string]interface{} `json:"metricValues"`
+		} `json:"items"`
+	}
+	if assert.NoError(t, json.Unmarshal(data, &actual)) && assert.Len(t, actual.Items, 1) {
+		assert.Equal(t, map[string]interface{}{"cpu": 500.0, "gc": "G1"}, actual.Items[0].ParameterValues)
+		assert.Equal(t, map[string]interface{}{"cost": 12.5}, actual.Items[0].MetricValues)
+	}
+}
+
+func TestTrialOutput_SetPrecision(t *testing.T) {
+	item := &experiments.TrialItem{
+		TrialAssignments: experiments.TrialAssignments{
+			Assignments: []experiments.Assignment{
+				{ParameterName: "cpu", Value: api.FromInt64(500)},
+				{ParameterName: "ratio", Value: api.FromFloat64(0.123456)},
+				{ParameterName: "gc", Value: api.FromString("G1")},
+			},
+		},
+		TrialValues: experiments.TrialValues{
+			Values: []experiments.Value{
+				{MetricName: "cost", Value: 12.3456789},
+				{MetricName: "latency", Value: 0.5},
+				{MetricName: "delta", Value: -0.0001},
+			},
+		},
+	}
+
+	cases := []struct {
+		desc        string
+		precision   int
+		assignments map[string]string
+		values      map[string]string
+	}{
+		{
+			desc:        "full",
+			precision:   -1,
+			assignments: map[string]string{"cpu": "500", "ratio": "0.123456", "gc": "G1"},
+			values:      map[string]string{"cost": "12.3456789", "latency": "0.5", "delta": "-0.0001"},
+		},
+		{
+			desc:        "two places",
+			precision:   2,
+			assignments: map[string]string{"cpu": "500", "ratio": "0.12", "gc": "G1"},
+			values:      map[string]string{"cost": "12.35", "latency": "0.5", "delta": "0"},
+		},
+		{
+			desc:        "integers",
+			precision:   0,
+			assignments: map[string]string{"cpu": "500", "ratio": "0", "gc": "G1"},
+			values:      map[string]string{"cost": "12", "latency": "0", "delta": "0"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			result := &TrialOutput{}
+			_ = result.Add(item)
+			result.SetPrecision(c.precision)
+			assert.Equal(t, c.assignments, result.Items[0].Assignments)
+			assert.Equal(t, c.values, result.Items[0].Values)
+
+			// JSON output always retains full precision
+			data, err := json.Marshal(result)
+			if assert.NoError(t, err) {
+				assert.Contains(t, string(data), `"cost":12.3456789`)
+				assert.Contains(t, string(data), `"ratio":0.123456`)
+			}
+		})
+	}
+}
+
+func TestTrialOutput_MarkBest(t *testing.T) {
+	exp := &experiments.Experiment{
+		Name: "fixture",
+		Metrics: []experiments.Metric{
+			{Name: "cost", Minimize: true},
+			{Name: "throughput"},
+			{Name: "errors", Minimize: true, Optimize: new(bool)},
+		},
+	}
+	trial := func(number int64, status experiments.TrialStatus, cost, throughput, errors float64) *experiments.TrialItem {
+		return &experiments.TrialItem{
+			Experiment: exp,
+			Number:     number,
+			Status:     status,
+			TrialValues: experiments.TrialValues{
+				Values: []experiments.Value{
+					{MetricName: "cost", Value: cost},
+					{MetricName: "throughput", Value: throughput},
+					{MetricName: "errors", Value: errors},
+				},
+			},
+		}
+	}
+
+	result := &TrialOutput{}
+	_ = result.Add(trial(1, experiments.TrialCompleted, 20, 300, 0))
+	_ = result.Add(trial(2, experiments.TrialCompleted, 10, 200, 5))
+	_ = result.Add(trial(3, experiments.TrialCompleted, 15, 250, 1))
+	_ = result.Add(trial(4, experiments.TrialFailed, 1, 1000, 0))
+	_ = result.Add(&experiments.TrialItem{Number: 5, Status: experiments.TrialCompleted})
+	result.MarkBest()
+
+	var best []string
+	for _, item := range result.Items {
+		best = append(best, item.Best)
+	}
+	assert.Equal(t, []string{"throughput↑", "cost↓", "", "", ""}, best)
+}
+
+func TestNDJSONPrinter_Fprint(t *testing.T) {
+	result := &TrialOutput{}
+	for i := int64(1); i <= 3; i++ {
+		_ = result.Add(&experiments.TrialItem{Number: i, Status: experiments.TrialCompleted})
+	}
+
+	var buf bytes.Buffer
+	if err := (&NDJSONPrinter{}).Fprint(&buf, result); !assert.NoError(t, err) {
+		return
+	}
+
+	var numbers []int64
+	s := bufio.NewScanner(&buf)
+	for s.Scan() {
+		var trial struct {
+			Number int64 `json:"number"`
+		}
+		if assert.NoError(t, json.Unmarshal(s.Bytes(), &trial), "line is not a valid JSON object: %s", s.Text()) {
+			numbers = append(numbers, trial.Number)
+		}
+	}
+	assert.Equal(t, []int64{1, 2, 3}, numbers)
+}
+
+func TestOutputFormat(t *testing.T) {
+	cases := []struct {
+		desc     string
+		cfg      Config
+		flag     string
+		expected string
+	}{
+		{
+			desc:     "default",
+			cfg:      &config.Config{},
+			expected: "{\n  \"number\": 1,\n  \"status\": \"completed\"\n}\n",
+		},
+		{
+			desc:     "preference",
+			cfg:      &config.Config{Preferences: config.Preferences{OutputFormat: "yaml"}},
+			expected: "number: 1\nstatus: completed\n",
+		},
+		{
+			desc:     "flag overrides preference",
+			cfg:      &config.Config{Preferences: config.Preferences{OutputFormat: "yaml"}},
+			flag:     "ndjson",
+			expected: "{\"number\":1,\"status\":\"completed\"}\n",
+		},
+		{
+			desc:     "no preference support",
+			cfg:      testConfig("http://example.com/"),
+			expected: "{\n  \"number\": 1,\n  \"status\": \"completed\"\n}\n",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			p, err := NewPrinter(OutputFormat(c.cfg, c.flag))
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			var buf bytes.Buffer
+			if assert.NoError(t, p.Fprint(&buf, map[string]interface{}{"number": 1, "status": "completed"})) {
+				assert.Equal(t, c.expected, buf.String())
+			}
+		})
+	}
+
+	_, err := NewPrinter("xml")
+	assert.Error(t, err)
+}
+
+func TestFormatTime(t *testing.T) {
+	now := time.Date(2022, time.March, 1, 12, 0, 0, 0, time.UTC)
+	defer func(c api.Clock) { clock = c }(clock)
+	clock = api.FixedClock(now)
+
+	then := now.Add(-3 * time.Hour)
+	assert.Equal(t, "", formatTime(nil, ""))
+	assert.Equal(t, "", formatTime(&time.Time{}, "ago"))
+	assert.Equal(t, "3 hours ago", formatTime(&then, "ago"))
+	assert.Equal(t, "3 hours", formatTime(&then, ""))
+	assert.Equal(t, "2022-03-01T09:00:00Z", formatTime(&then, time.RFC3339))
+}
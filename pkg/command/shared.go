@@ -18,7 +18,12 @@ package command
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/thestormforge/optimize-go/pkg/api"
@@ -32,6 +37,78 @@ type Config interface {
 	Address() string
 }
 
+// newClient returns a new API client for the configuration.
+func newClient(ctx context.Context, cfg Config) (api.Client, error) {
+	var opts []api.ClientOption
+	if co, ok := cfg.(interface {
+		ClientOptions(context.Context) []api.ClientOption
+	}); ok {
+		opts = co.ClientOptions(ctx)
+	}
+	return api.NewClient(cfg.Address(), nil, opts...)
+}
+
+// applyPreferences overwrites the values of flags which were not explicitly set
+// using the client-side preferences of the configuration (if it has any).
+func applyPreferences(cmd *cobra.Command, cfg Config) error {
+	if f := cmd.Flags().Lookup("poll"); f != nil && !f.Changed {
+		if pref, ok := cfg.(interface{ PollInterval() time.Duration }); ok {
+			if err := f.Value.Set(pref.PollInterval().String()); err != nil {
+				return err
+			}
+		}
+	}
+
+	if f := cmd.Flags().Lookup("batch-size"); f != nil && !f.Changed {
+		if pref, ok := cfg.(interface{ PageSize() int }); ok && pref.PageSize() > 0 {
+			if err := f.Value.Set(strconv.Itoa(pref.PageSize())); err != nil {
+				return err
+			}
+		}
+	}
+
+	if f := cmd.Flags().Lookup("precision"); f != nil && !f.Changed {
+		if pref, ok := cfg.(interface{ Precision() int }); ok {
+			if err := f.Value.Set(strconv.Itoa(pref.Precision())); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// newProgress returns a function for rendering the progress of a list operation
+// to the error stream along with a function to clear it once the operation is
+// complete. Progress is only rendered when both the output and error streams are
+// terminals, i.e. the output is not being consumed by another program.
+func newProgress(cmd *cobra.Command, noun string) (func(visited, total int), func()) {
+	errOut := cmd.ErrOrStderr()
+	if !isTerminal(cmd.OutOrStdout()) || !isTerminal(errOut) {
+		return nil, func() {}
+	}
+
+	progress := func(visited, total int) {
+		if total > 0 {
+			_, _ = fmt.Fprintf(errOut, "\rfetched %d of %d %s", visited, total, noun)
+		} else {
+			_, _ = fmt.Fprintf(errOut, "\rfetched %d %s", visited, noun)
+		}
+	}
+	done := func() { _, _ = fmt.Fprint(errOut, "\r\033[K") }
+	return progress, done
+}
+
+// isTerminal checks to see if the supplied writer is a character device.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
 // parseLabelSelector returns a map of simple equality based label selectors.
 func parseLabelSelector(s string) map[string]string {
 	if s == "" {
@@ -53,9 +130,46 @@ func parseLabelSelector(s string) map[string]string {
 	return selector
 }
 
+// argsToNamesAndLabels splits positional arguments into resource names and label
+// changes. Labels are specified as `key=value` pairs, a `key-` removes the label by
+// assigning it an empty value.
+func argsToNamesAndLabels(args []string) ([]string, map[string]string, error) {
+	var names []string
+	labels := make(map[string]string)
+	for _, arg := range args {
+		switch {
+		case strings.Contains(arg, "="):
+			pair := strings.SplitN(arg, "=", 2)
+			if pair[0] == "" {
+				return nil, nil, fmt.Errorf("invalid label %q", arg)
+			}
+			labels[pair[0]] = pair[1]
+		case strings.HasSuffix(arg, "-"):
+			key := strings.TrimSuffix(arg, "-")
+			if key == "" {
+				return nil, nil, fmt.Errorf("invalid label removal %q", arg)
+			}
+			labels[key] = ""
+		default:
+			if len(labels) > 0 {
+				return nil, nil, fmt.Errorf("names must be specified before labels: %q", arg)
+			}
+			names = append(names, arg)
+		}
+	}
+
+	if len(names) == 0 {
+		return nil, nil, fmt.Errorf("at least one name is required")
+	}
+	if len(labels) == 0 {
+		return nil, nil, fmt.Errorf("at least one label change is required")
+	}
+	return names, labels, nil
+}
+
 func validArgs(cfg Config, f func(*completionLister, string) ([]string, cobra.ShellCompDirective)) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
 	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-		client, err := api.NewClient(cfg.Address(), nil)
+		client, err := newClient(cmd.Context(), cfg)
 		if err != nil {
 			return nil, cobra.ShellCompDirectiveNoFileComp
 		}
@@ -0,0 +1,68 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/thestormforge/optimize-go/pkg/config"
+)
+
+func TestApplyPreferences(t *testing.T) {
+	precision := 2
+	cfg := &config.Config{Preferences: config.Preferences{PollInterval: time.Minute, PageSize: 25, Precision: &precision}}
+
+	cases := []struct {
+		desc      string
+		args      []string
+		poll      time.Duration
+		batchSize int
+		precision int
+	}{
+		{
+			desc:      "preferences",
+			poll:      time.Minute,
+			batchSize: 25,
+			precision: 2,
+		},
+		{
+			desc:      "flags override preferences",
+			args:      []string{"--poll", "5s", "--batch-size", "10", "--precision", "-1"},
+			poll:      5 * time.Second,
+			batchSize: 10,
+			precision: -1,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			var poll time.Duration
+			var batchSize, precision int
+			cmd := &cobra.Command{}
+			cmd.Flags().DurationVar(&poll, "poll", 30*time.Second, "")
+			cmd.Flags().IntVar(&batchSize, "batch-size", 0, "")
+			cmd.Flags().IntVar(&precision, "precision", -1, "")
+			if assert.NoError(t, cmd.ParseFlags(c.args)) && assert.NoError(t, applyPreferences(cmd, cfg)) {
+				assert.Equal(t, c.poll, poll)
+				assert.Equal(t, c.batchSize, batchSize)
+				assert.Equal(t, c.precision, precision)
+			}
+		})
+	}
+}
@@ -17,8 +17,18 @@ limitations under the License.
 package command
 
 import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/thestormforge/optimize-go/pkg/api"
@@ -42,7 +52,7 @@ func NewCreateTrialCommand(cfg Config, p Printer) *cobra.Command {
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		ctx, out := cmd.Context(), cmd.OutOrStdout()
-		client, err := api.NewClient(cfg.Address(), nil)
+		client, err := newClient(cmd.Context(), cfg)
 		if err != nil {
 			return err
 		}
@@ -91,20 +101,26 @@ func NewCreateTrialCommand(cfg Config, p Printer) *cobra.Command {
 // NewEditTrialCommand returns a command for editing a trial.
 func NewEditTrialCommand(cfg Config, p Printer) *cobra.Command {
 	var (
-		labels map[string]string
+		labels   map[string]string
+		selector string
 	)
 
 	cmd := &cobra.Command{
-		Use:               "trial EXP_NAME/TRIAL_NUM",
-		Args:              cobra.ExactArgs(1),
+		Use:               "trial EXP_NAME/TRIAL_NUM | --selector QUERY",
+		Args:              cobra.MaximumNArgs(1),
 		ValidArgsFunction: validTrialArgs(cfg),
 	}
 
 	cmd.Flags().StringToStringVar(&labels, "set-label", nil, "label `key=value` pairs to assign")
+	cmd.Flags().StringVarP(&selector, "selector", "l", selector, "edit completed trials of experiments matching the selector (label `query`)")
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		ctx, out := cmd.Context(), cmd.OutOrStdout()
-		client, err := api.NewClient(cfg.Address(), nil)
+		if (len(args) == 0) == (selector == "") {
+			return fmt.Errorf("exactly one of a trial name or an experiment selector is required")
+		}
+
+		client, err := newClient(cmd.Context(), cfg)
 		if err != nil {
 			return err
 		}
@@ -113,9 +129,7 @@ func NewEditTrialCommand(cfg Config, p Printer) *cobra.Command {
 			API: experiments.NewAPI(client),
 		}
 
-		q := experiments.TrialListQuery{}
-		q.SetStatus(experiments.TrialCompleted)
-		return l.ForEachNamedTrial(ctx, args, q, false, func(item *experiments.TrialItem) error {
+		editTrial := func(item *experiments.TrialItem) error {
 			// Apply label changes
 			if len(labels) > 0 {
 				labelsURL := item.Link(api.RelationLabels)
@@ -130,6 +144,28 @@ func NewEditTrialCommand(cfg Config, p Printer) *cobra.Command {
 			}
 
 			return p.Fprint(out, NewTrialRow(item))
+		}
+
+		q := experiments.TrialListQuery{}
+		q.SetStatus(experiments.TrialCompleted)
+		if selector == "" {
+			return l.ForEachNamedTrial(ctx, args, q, false, editTrial)
+		}
+
+		// Edit the trials of every experiment matching the selector
+		eq := experiments.ExperimentListQuery{}
+		eq.SetLabelSelector(parseLabelSelector(selector))
+		return l.ForEachExperiment(ctx, eq, func(item *experiments.ExperimentItem) error {
+			count := 0
+			if err := l.ForEachTrial(ctx, &item.Experiment, q, func(item *experiments.TrialItem) error {
+				count++
+				return editTrial(item)
+			}); err != nil {
+				return err
+			}
+
+			_, err := fmt.Fprintf(cmd.ErrOrStderr(), "edited %d trial(s) for experiment %q\n", count, item.Name)
+			return err
 		})
 	}
 	return cmd
@@ -138,9 +174,12 @@ func NewEditTrialCommand(cfg Config, p Printer) *cobra.Command {
 // NewGetTrialsCommand returns a command for getting trials.
 func NewGetTrialsCommand(cfg Config, p Printer) *cobra.Command {
 	var (
-		selector string
-		all      bool
-		sortBy   string
+		selector  string
+		all       bool
+		status    []string
+		limit     int
+		sortBy    string
+		precision int
 	)
 
 	cmd := &cobra.Command{
@@ -152,29 +191,53 @@ func NewGetTrialsCommand(cfg Config, p Printer) *cobra.Command {
 
 	cmd.Flags().StringVarP(&selector, "selector", "l", selector, "selector (label `query`) to filter on")
 	cmd.Flags().BoolVarP(&all, "all", "A", all, "include all resources")
+	cmd.Flags().StringSliceVar(&status, "status", nil, "include only trials with the specified `status`es; any of: staged|active|completed|failed|abandoned")
+	cmd.Flags().IntVar(&limit, "limit", limit, "stop after `n` trials")
 	cmd.Flags().StringVar(&sortBy, "sort-by", sortBy, "sort using `column` name")
+	cmd.Flags().IntVar(&precision, "precision", -1, "round displayed numbers to `n` decimal places; negative values display full precision")
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		ctx, out := cmd.Context(), cmd.OutOrStdout()
-		client, err := api.NewClient(cfg.Address(), nil)
+		if err := applyPreferences(cmd, cfg); err != nil {
+			return err
+		}
+
+		client, err := newClient(cmd.Context(), cfg)
 		if err != nil {
 			return err
 		}
 
 		l := experiments.Lister{
-			API: experiments.NewAPI(client),
+			API:         experiments.NewAPI(client),
+			MaxItems:    limit,
+			Concurrency: 4,
 		}
 
-		result := &TrialOutput{Items: make([]TrialRow, 0, len(args))}
+		q, err := trialListQuery(selector, all, status)
+		if err != nil {
+			return err
+		}
 
-		q := experiments.TrialListQuery{}
-		q.SetLabelSelector(parseLabelSelector(selector))
-		q.SetStatus(experiments.TrialActive, experiments.TrialCompleted, experiments.TrialFailed)
-		if all {
-			q.AddStatus(experiments.TrialStaged)
+		// Streaming output prints each trial as it is visited
+		if IsStreaming(p) {
+			if sortBy != "" {
+				return fmt.Errorf("sorting is not supported with streaming output")
+			}
+			return l.ForEachNamedTrial(ctx, args, q, false, func(item *experiments.TrialItem) error {
+				row := NewTrialRow(item)
+				row.SetPrecision(precision)
+				return p.Fprint(out, row)
+			})
 		}
 
-		if err := l.ForEachNamedTrial(ctx, args, q, false, result.Add); err != nil {
+		// Only render progress when the results are buffered
+		progress, done := newProgress(cmd, "trials")
+		l.Progress = progress
+
+		result := &TrialOutput{Items: make([]TrialRow, 0, len(args))}
+		err = l.ForEachNamedTrial(ctx, args, q, false, result.Add)
+		done()
+		if err != nil {
 			return err
 		}
 
@@ -182,11 +245,121 @@ func NewGetTrialsCommand(cfg Config, p Printer) *cobra.Command {
 			return err
 		}
 
+		result.SetPrecision(precision)
+		result.MarkBest()
 		return p.Fprint(out, result)
 	}
 	return cmd
 }
 
+// NewGetBestTrialCommand returns a command for getting the best trial of an experiment.
+func NewGetBestTrialCommand(cfg Config, p Printer) *cobra.Command {
+	var (
+		objective string
+		weights   map[string]string
+		values    bool
+		mapping   map[string]string
+	)
+
+	cmd := &cobra.Command{
+		Use:               "best-trial EXP_NAME",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: validExperimentArgs(cfg),
+	}
+
+	cmd.Flags().StringVar(&objective, "objective", "", "the `metric` used to select the best trial; defaults to the first optimized metric")
+	cmd.Flags().StringToStringVar(&weights, "weights", nil, "`metric=weight` pairs used to select the best trial using a weighted score of multiple metrics")
+	cmd.Flags().BoolVar(&values, "values", false, "print the assignments of the best trial as a values file")
+	cmd.Flags().StringToStringVar(&mapping, "values-key", nil, "`parameter=key` pairs mapping parameters to dotted keys in the values output")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx, out := cmd.Context(), cmd.OutOrStdout()
+		if objective != "" && len(weights) > 0 {
+			return fmt.Errorf("only one of --objective or --weights may be specified")
+		}
+		metricWeights, err := parseWeights(weights)
+		if err != nil {
+			return err
+		}
+
+		client, err := newClient(cmd.Context(), cfg)
+		if err != nil {
+			return err
+		}
+
+		l := experiments.Lister{
+			API: experiments.NewAPI(client),
+		}
+
+		exp, err := l.API.GetExperimentByName(ctx, experiments.ExperimentName(args[0]))
+		if err != nil {
+			return err
+		}
+
+		var metric experiments.Metric
+		var ok bool
+		if len(metricWeights) > 0 {
+			for name := range metricWeights {
+				if _, ok := experiments.ObjectiveMetric(&exp, name); !ok {
+					return fmt.Errorf("unable to find weighted metric %q for experiment %q", name, args[0])
+				}
+			}
+		} else if metric, ok = experiments.ObjectiveMetric(&exp, objective); !ok {
+			return fmt.Errorf("unable to find objective metric for experiment %q", args[0])
+		}
+
+		q, err := trialListQuery("", false, []string{string(experiments.TrialCompleted)})
+		if err != nil {
+			return err
+		}
+
+		var trials []experiments.TrialItem
+		if err := l.ForEachTrial(ctx, &exp, q, func(item *experiments.TrialItem) error {
+			trials = append(trials, *item)
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		var best *experiments.TrialItem
+		if len(metricWeights) > 0 {
+			if best, ok = experiments.BestWeightedTrial(trials, exp.Metrics, metricWeights); !ok {
+				return fmt.Errorf("no completed trials reporting all weighted metrics for experiment %q", args[0])
+			}
+		} else if best, ok = experiments.BestTrial(trials, metric); !ok {
+			return fmt.Errorf("no completed trials reporting %q for experiment %q", metric.Name, args[0])
+		}
+
+		if values {
+			b, err := experiments.ToValuesYAML(best.TrialAssignments, mapping)
+			if err != nil {
+				return err
+			}
+			_, err = out.Write(b)
+			return err
+		}
+
+		return p.Fprint(out, NewTrialRow(best))
+	}
+	return cmd
+}
+
+// parseWeights converts metric weight flag values into numbers.
+func parseWeights(weights map[string]string) (map[string]float64, error) {
+	result := make(map[string]float64, len(weights))
+	for name, value := range weights {
+		w, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid weight for metric %q: %w", name, err)
+		}
+		if w <= 0 {
+			return nil, fmt.Errorf("invalid weight for metric %q: must be positive", name)
+		}
+		result[name] = w
+	}
+	return result, nil
+}
+
 // NewDeleteTrialsCommand returns a command for deleting ("abandoning") trials.
 func NewDeleteTrialsCommand(cfg Config, p Printer) *cobra.Command {
 	var (
@@ -202,7 +375,7 @@ func NewDeleteTrialsCommand(cfg Config, p Printer) *cobra.Command {
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		ctx, out := cmd.Context(), cmd.OutOrStdout()
-		client, err := api.NewClient(cfg.Address(), nil)
+		client, err := newClient(cmd.Context(), cfg)
 		if err != nil {
 			return err
 		}
@@ -230,6 +403,299 @@ func NewDeleteTrialsCommand(cfg Config, p Printer) *cobra.Command {
 	return cmd
 }
 
+// NewReportTrialsCommand returns a command for reporting trial values read from a file.
+func NewReportTrialsCommand(cfg Config, p Printer) *cobra.Command {
+	var (
+		filename string
+		format   string
+	)
+
+	cmd := &cobra.Command{
+		Use:               "trials EXP_NAME -f FILE",
+		Aliases:           []string{"trial"},
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: validExperimentArgs(cfg),
+	}
+
+	cmd.Flags().StringVarP(&filename, "filename", "f", "", "`file` containing the trial reports, use - for stdin")
+	cmd.Flags().StringVar(&format, "format", "", "the file `format`; one of: ndjson|csv (default is based on the file extension)")
+	_ = cmd.MarkFlagRequired("filename")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx, out := cmd.Context(), cmd.OutOrStdout()
+
+		// Read all the reports up front so we do not report a partial file
+		var r io.Reader
+		if filename == "-" {
+			r = cmd.InOrStdin()
+		} else {
+			f, err := os.Open(filename)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			r = f
+		}
+		if format == "" && strings.EqualFold(filepath.Ext(filename), ".csv") {
+			format = "csv"
+		}
+		reports, err := readTrialReports(r, format)
+		if err != nil {
+			return err
+		}
+
+		client, err := newClient(cmd.Context(), cfg)
+		if err != nil {
+			return err
+		}
+
+		l := experiments.Lister{
+			API: experiments.NewAPI(client),
+		}
+
+		exp, err := l.API.GetExperimentByName(ctx, experiments.ExperimentName(args[0]))
+		if err != nil {
+			return err
+		}
+
+		// Index the trials which can still be reported by their assignments
+		trials := make(map[string]*experiments.TrialItem)
+		q := experiments.TrialListQuery{}
+		q.SetStatus(experiments.TrialActive, experiments.TrialStaged)
+		if err := l.ForEachTrial(ctx, &exp, q, func(item *experiments.TrialItem) error {
+			values := make(map[string]api.NumberOrString, len(item.Assignments))
+			for _, a := range item.Assignments {
+				values[a.ParameterName] = a.Value
+			}
+			if key, err := assignmentKey(&exp, values); err == nil {
+				trials[key] = item
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		var failed int
+		for i := range reports {
+			// Allow trial URLs relative to the server address
+			if reports[i].Trial != "" {
+				reports[i].Trial = client.URL(reports[i].Trial).String()
+			}
+
+			item, err := reports[i].report(ctx, l.API, &exp, trials)
+			if err != nil {
+				failed++
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "row %d: %v\n", i+1, err)
+				continue
+			}
+			if err := p.Fprint(out, NewTrialRow(item)); err != nil {
+				return err
+			}
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("failed to report %d of %d trials", failed, len(reports))
+		}
+		return nil
+	}
+	return cmd
+}
+
+// trialReport is a single trial report read from a file. The JSON representation
+// matches the trial output so the values of previously exported trials can be replayed.
+type trialReport struct {
+	Trial           string                        `json:"trial,omitempty"`
+	ParameterValues map[string]api.NumberOrString `json:"parameterValues,omitempty"`
+	MetricValues    map[string]float64            `json:"metricValues,omitempty"`
+	Failed          bool                          `json:"failed,omitempty"`
+	FailureReason   string                        `json:"failureReason,omitempty"`
+	FailureMessage  string                        `json:"failureMessage,omitempty"`
+
+	trialOutput
+}
+
+// trialOutput holds the remaining fields of the trial output. The assignments and values
+// are only used when the parameter and metric values are missing, the rest are ignored.
+type trialOutput struct {
+	Assignments    []experiments.Assignment `json:"assignments,omitempty"`
+	Values         []experiments.Value      `json:"values,omitempty"`
+	Labels         map[string]string        `json:"labels,omitempty"`
+	Status         string                   `json:"status,omitempty"`
+	Number         int64                    `json:"number,omitempty"`
+	StartTime      *time.Time               `json:"startTime,omitempty"`
+	CompletionTime *time.Time               `json:"completionTime,omitempty"`
+}
+
+// fromTrialOutput fills in the parameter and metric values using the trial output.
+func (r *trialReport) fromTrialOutput() {
+	if len(r.ParameterValues) == 0 && len(r.Assignments) > 0 {
+		r.ParameterValues = make(map[string]api.NumberOrString, len(r.Assignments))
+		for _, a := range r.Assignments {
+			r.ParameterValues[a.ParameterName] = a.Value
+		}
+	}
+	if len(r.MetricValues) == 0 && len(r.Values) > 0 {
+		r.MetricValues = make(map[string]float64, len(r.Values))
+		for _, v := range r.Values {
+			r.MetricValues[v.MetricName] = v.Value
+		}
+	}
+}
+
+// report sends the values to the trial with a matching URL or matching assignments.
+func (r *trialReport) report(ctx context.Context, expAPI experiments.API, exp *experiments.Experiment, trials map[string]*experiments.TrialItem) (*experiments.TrialItem, error) {
+	item := &experiments.TrialItem{Experiment: exp}
+	u := r.Trial
+	if u == "" {
+		if len(r.ParameterValues) == 0 {
+			return nil, fmt.Errorf("either a trial URL or parameter values are required")
+		}
+
+		key, err := assignmentKey(exp, r.ParameterValues)
+		if err != nil {
+			return nil, err
+		}
+		t, ok := trials[key]
+		if !ok {
+			return nil, fmt.Errorf("no active trial matches the parameter values")
+		}
+		if u = t.Link(api.RelationSelf); u == "" {
+			return nil, fmt.Errorf("malformed response, missing self link")
+		}
+		item = t
+	}
+
+	item.TrialValues = experiments.TrialValues{
+		Failed:         r.Failed || r.FailureReason != "",
+		FailureReason:  r.FailureReason,
+		FailureMessage: r.FailureMessage,
+	}
+	for _, m := range exp.Metrics {
+		if v, ok := r.MetricValues[m.Name]; ok {
+			item.Values = append(item.Values, experiments.Value{MetricName: m.Name, Value: v})
+		}
+	}
+	if len(item.Values) < len(r.MetricValues) {
+		return nil, fmt.Errorf("metric values do not match the experiment metrics")
+	}
+
+	if err := expAPI.ReportTrial(ctx, u, item.TrialValues); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// assignmentKey returns a string used to match parameter assignments. Values are
+// normalized using the experiment parameters so equivalent numbers (e.g. "1.0" and
+// "1") produce the same key.
+func assignmentKey(exp *experiments.Experiment, values map[string]api.NumberOrString) (string, error) {
+	key := make([]string, 0, len(values))
+	for k, v := range values {
+		value := v.String()
+		for i := range exp.Parameters {
+			if exp.Parameters[i].Name != k {
+				continue
+			}
+
+			pv, err := exp.Parameters[i].ParseValue(value)
+			if err != nil {
+				return "", fmt.Errorf("invalid value for parameter %q: %w", k, err)
+			}
+			if !pv.IsString {
+				f, err := pv.NumVal.Float64()
+				if err != nil {
+					return "", fmt.Errorf("invalid value for parameter %q: %w", k, err)
+				}
+				value = strconv.FormatFloat(f, 'f', -1, 64)
+			}
+			break
+		}
+		key = append(key, k+"="+value)
+	}
+	sort.Strings(key)
+	return strings.Join(key, ","), nil
+}
+
+// readTrialReports reads trial reports from newline-delimited JSON or CSV.
+func readTrialReports(r io.Reader, format string) ([]trialReport, error) {
+	switch strings.ToLower(format) {
+	case "", "ndjson":
+		var reports []trialReport
+		dec := json.NewDecoder(r)
+		dec.DisallowUnknownFields()
+		for {
+			report := trialReport{}
+			if err := dec.Decode(&report); errors.Is(err, io.EOF) {
+				return reports, nil
+			} else if err != nil {
+				return nil, fmt.Errorf("row %d: %w", len(reports)+1, err)
+			}
+			report.fromTrialOutput()
+			reports = append(reports, report)
+		}
+
+	case "csv":
+		records, err := csv.NewReader(r).ReadAll()
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			return nil, nil
+		}
+
+		header, reports := records[0], make([]trialReport, 0, len(records)-1)
+		for _, column := range header {
+			switch {
+			case column == "trial", column == "failed", column == "failure_reason", column == "failure_message":
+			case strings.HasPrefix(column, "parameter_"), strings.HasPrefix(column, "metric_"):
+			default:
+				return nil, fmt.Errorf("unknown column %q", column)
+			}
+		}
+		for i, record := range records[1:] {
+			report := trialReport{}
+			for j, value := range record {
+				if value == "" {
+					continue
+				}
+				switch column := header[j]; {
+				case column == "trial":
+					report.Trial = value
+				case column == "failed":
+					if report.Failed, err = strconv.ParseBool(value); err != nil {
+						return nil, fmt.Errorf("row %d: %w", i+1, err)
+					}
+				case column == "failure_reason":
+					report.FailureReason = value
+				case column == "failure_message":
+					report.FailureMessage = value
+				case strings.HasPrefix(column, "parameter_"):
+					if report.ParameterValues == nil {
+						report.ParameterValues = make(map[string]api.NumberOrString)
+					}
+					if _, err := strconv.ParseFloat(value, 64); err == nil {
+						report.ParameterValues[strings.TrimPrefix(column, "parameter_")] = api.FromNumber(json.Number(value))
+					} else {
+						report.ParameterValues[strings.TrimPrefix(column, "parameter_")] = api.FromString(value)
+					}
+				case strings.HasPrefix(column, "metric_"):
+					if report.MetricValues == nil {
+						report.MetricValues = make(map[string]float64)
+					}
+					if report.MetricValues[strings.TrimPrefix(column, "metric_")], err = strconv.ParseFloat(value, 64); err != nil {
+						return nil, fmt.Errorf("row %d: %w", i+1, err)
+					}
+				}
+			}
+			reports = append(reports, report)
+		}
+		return reports, nil
+
+	default:
+		return nil, fmt.Errorf("unknown format %q, must be one of: ndjson, csv", format)
+	}
+}
+
 func validTrialArgs(cfg Config) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
 	return validArgs(cfg, func(l *completionLister, toComplete string) (completions []string, directive cobra.ShellCompDirective) {
 		directive |= cobra.ShellCompDirectiveNoFileComp
@@ -248,6 +714,32 @@ func validTrialArgs(cfg Config) func(*cobra.Command, []string, string) ([]string
 	})
 }
 
+// trialListQuery returns the query used to list trials. Explicitly requested
+// statuses override the default set of active, completed and failed trials.
+func trialListQuery(selector string, all bool, status []string) (experiments.TrialListQuery, error) {
+	q := experiments.TrialListQuery{}
+	q.SetLabelSelector(parseLabelSelector(selector))
+
+	if len(status) > 0 {
+		ts := make([]experiments.TrialStatus, 0, len(status))
+		for _, s := range status {
+			st, err := experiments.ParseTrialStatus(s)
+			if err != nil {
+				return q, err
+			}
+			ts = append(ts, st)
+		}
+		q.SetStatus(ts...)
+		return q, nil
+	}
+
+	q.SetStatus(experiments.TrialActive, experiments.TrialCompleted, experiments.TrialFailed)
+	if all {
+		q.AddStatus(experiments.TrialStaged)
+	}
+	return q, nil
+}
+
 func parameterValue(p *experiments.Parameter, assignments map[string]string, defaultBehavior string) (*api.NumberOrString, error) {
 	if a, ok := assignments[p.Name]; ok {
 		return p.ParseValue(a)
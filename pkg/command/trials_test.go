@@ -0,0 +1,357 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testConfig is a command configuration that points to a test server.
+type testConfig string
+
+func (c testConfig) Address() string { return string(c) }
+
+// discardPrinter is a printer that ignores everything.
+type discardPrinter struct{}
+
+func (discardPrinter) Fprint(io.Writer, interface{}) error { return nil }
+
+func TestEditTrialCommand_Selector(t *testing.T) {
+	var mu sync.Mutex
+	labeled := make(map[string]map[string]string)
+
+	var srv *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/experiments/", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "team=a", r.URL.Query().Get("labelSelector"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"experiments": [
+  {"_metadata": {"Link": ["<%[1]s/v1/experiments/one>; rel=self", "<%[1]s/v1/experiments/one/trials>; rel=https://stormforge.io/rel/trials"]}},
+  {"_metadata": {"Link": ["<%[1]s/v1/experiments/two>; rel=self", "<%[1]s/v1/experiments/two/trials>; rel=https://stormforge.io/rel/trials"]}}
+]}`, srv.URL)
+	})
+	for _, name := range []string{"one", "two"} {
+		name := name
+		mux.HandleFunc("/v1/experiments/"+name+"/trials", func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "completed", r.URL.Query().Get("status"))
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = fmt.Fprintf(w, `{"trials": [{"_metadata": {"Link": "<%s/v1/experiments/%s/trials/1/labels>; rel=https://stormforge.io/rel/labels"}, "number": 1, "status": "completed"}]}`, srv.URL, name)
+		})
+		mux.HandleFunc("/v1/experiments/"+name+"/trials/1/labels", func(w http.ResponseWriter, r *http.Request) {
+			lbl := struct {
+				Labels map[string]string `json:"labels"`
+			}{}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&lbl))
+			mu.Lock()
+			labeled[name] = lbl.Labels
+			mu.Unlock()
+			w.WriteHeader(http.StatusCreated)
+		})
+	}
+	srv = httptest.NewServer(mux)
+	defer srv.Close()
+
+	var stderr bytes.Buffer
+	cmd := NewEditTrialCommand(testConfig(srv.URL+"/"), discardPrinter{})
+	cmd.SetArgs([]string{"--selector", "team=a", "--set-label", "best=true"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(&stderr)
+	if assert.NoError(t, cmd.ExecuteContext(context.Background())) {
+		assert.Equal(t, map[string]map[string]string{
+			"one": {"best": "true"},
+			"two": {"best": "true"},
+		}, labeled)
+		assert.Contains(t, stderr.String(), `experiment "one"`)
+		assert.Contains(t, stderr.String(), `experiment "two"`)
+	}
+}
+
+func TestTrialListQuery(t *testing.T) {
+	cases := []struct {
+		desc     string
+		all      bool
+		status   []string
+		expected string
+		err      bool
+	}{
+		{
+			desc:     "default",
+			expected: "active,completed,failed",
+		},
+		{
+			desc:     "all",
+			all:      true,
+			expected: "active,completed,failed,staged",
+		},
+		{
+			desc:     "explicit status",
+			status:   []string{"abandoned", "failed"},
+			expected: "abandoned,failed",
+		},
+		{
+			desc:     "explicit status overrides all",
+			all:      true,
+			status:   []string{"aborted"},
+			expected: "abandoned",
+		},
+		{
+			desc:   "invalid status",
+			status: []string{"pending"},
+			err:    true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			q, err := trialListQuery("", c.all, c.status)
+			if c.err {
+				assert.Error(t, err)
+			} else if assert.NoError(t, err) {
+				assert.Equal(t, c.expected, url.Values(q.IndexQuery).Get("status"))
+			}
+		})
+	}
+}
+
+func TestGetBestTrialCommand_Values(t *testing.T) {
+	var srv *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/experiments/fixture", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Link", fmt.Sprintf("<%s/v1/experiments/fixture/trials>; rel=https://stormforge.io/rel/trials", srv.URL))
+		_, _ = fmt.Fprint(w, `{"metrics": [{"name": "cost", "minimize": true}, {"name": "throughput"}]}`)
+	})
+	mux.HandleFunc("/v1/experiments/fixture/trials", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "completed", r.URL.Query().Get("status"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"trials": [
+  {"number": 1, "status": "completed", "assignments": [{"parameterName": "cpu", "value": 1000}, {"parameterName": "gc", "value": "G1"}], "values": [{"metricName": "cost", "value": 20}, {"metricName": "throughput", "value": 300}]},
+  {"number": 2, "status": "completed", "assignments": [{"parameterName": "cpu", "value": 500}, {"parameterName": "gc", "value": "Parallel"}], "values": [{"metricName": "cost", "value": 10}, {"metricName": "throughput", "value": 200}]}
+]}`)
+	})
+	srv = httptest.NewServer(mux)
+	defer srv.Close()
+
+	// The global output format must not be shadowed by the command
+	assert.Nil(t, NewGetBestTrialCommand(testConfig(srv.URL+"/"), discardPrinter{}).Flags().Lookup("output"))
+
+	cases := []struct {
+		desc     string
+		args     []string
+		expected string
+	}{
+		{
+			desc: "default objective",
+			args: []string{"fixture", "--values", "--values-key", "cpu=resources.requests.cpu"},
+			expected: `gc: Parallel
+resources:
+  requests:
+    cpu: 500
+`,
+		},
+		{
+			desc: "explicit objective",
+			args: []string{"fixture", "--values", "--objective", "throughput"},
+			expected: `cpu: 1000
+gc: G1
+`,
+		},
+		{
+			desc: "weighted objectives",
+			args: []string{"fixture", "--values", "--weights", "cost=3,throughput=1"},
+			expected: `cpu: 500
+gc: Parallel
+`,
+		},
+		{
+			desc: "weighted objectives favoring throughput",
+			args: []string{"fixture", "--values", "--weights", "cost=1,throughput=3"},
+			expected: `cpu: 1000
+gc: G1
+`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := NewGetBestTrialCommand(testConfig(srv.URL+"/"), discardPrinter{})
+			cmd.SetArgs(c.args)
+			cmd.SetOut(&out)
+			cmd.SetErr(io.Discard)
+			if assert.NoError(t, cmd.ExecuteContext(context.Background())) {
+				assert.Equal(t, c.expected, out.String())
+			}
+		})
+	}
+}
+
+func TestReportTrialsCommand(t *testing.T) {
+	var mu sync.Mutex
+	var reported map[string]string
+
+	var srv *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/experiments/fixture", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Link", fmt.Sprintf("<%s/v1/experiments/fixture/trials>; rel=https://stormforge.io/rel/trials", srv.URL))
+		_, _ = fmt.Fprint(w, `{"parameters": [{"name": "cpu", "type": "double"}, {"name": "gc", "type": "categorical"}], "metrics": [{"name": "cost", "minimize": true}, {"name": "throughput"}]}`)
+	})
+	mux.HandleFunc("/v1/experiments/fixture/trials", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "active,staged", r.URL.Query().Get("status"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"trials": [
+  {"_metadata": {"Link": "<%[1]s/v1/experiments/fixture/trials/1>; rel=self"}, "number": 1, "status": "active", "assignments": [{"parameterName": "cpu", "value": 100}, {"parameterName": "gc", "value": "G1"}]},
+  {"_metadata": {"Link": "<%[1]s/v1/experiments/fixture/trials/2>; rel=self"}, "number": 2, "status": "active", "assignments": [{"parameterName": "cpu", "value": 200}, {"parameterName": "gc", "value": "Parallel"}]}
+]}`, srv.URL)
+	})
+	mux.HandleFunc("/v1/experiments/fixture/trials/", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		if r.URL.Path == "/v1/experiments/fixture/trials/9" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		reported[r.URL.Path] = string(body)
+		mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+	})
+	srv = httptest.NewServer(mux)
+	defer srv.Close()
+
+	for _, filename := range []string{"testdata/trials.ndjson", "testdata/trials.csv"} {
+		t.Run(filename, func(t *testing.T) {
+			reported = make(map[string]string)
+			p := &capturePrinter{}
+			var stderr bytes.Buffer
+			cmd := NewReportTrialsCommand(testConfig(srv.URL+"/"), p)
+			cmd.SetArgs([]string{"fixture", "-f", filename})
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(&stderr)
+
+			err := cmd.ExecuteContext(context.Background())
+			assert.EqualError(t, err, "failed to report 2 of 4 trials")
+			assert.Contains(t, stderr.String(), "row 3: no active trial matches the parameter values")
+			assert.Contains(t, stderr.String(), "row 4: ")
+			assert.Len(t, p.objs, 2)
+			assert.Len(t, reported, 2)
+			assert.JSONEq(t, `{"values": [{"metricName": "cost", "value": 10}, {"metricName": "throughput", "value": 300}]}`, reported["/v1/experiments/fixture/trials/1"])
+			assert.JSONEq(t, `{"failed": true, "failureReason": "OOMKilled", "failureMessage": "out of memory"}`, reported["/v1/experiments/fixture/trials/2"])
+		})
+	}
+}
+
+func TestReportTrialsCommand_RoundTrip(t *testing.T) {
+	var mu sync.Mutex
+	reported := make(map[string]string)
+
+	var srv *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/experiments/fixture", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Link", fmt.Sprintf("<%s/v1/experiments/fixture/trials>; rel=https://stormforge.io/rel/trials", srv.URL))
+		_, _ = fmt.Fprint(w, `{"parameters": [{"name": "cpu", "type": "double"}, {"name": "gc", "type": "categorical"}], "metrics": [{"name": "cost", "minimize": true}]}`)
+	})
+	mux.HandleFunc("/v1/experiments/fixture/trials", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"trials": [
+  {"_metadata": {"Link": "<%[1]s/v1/experiments/fixture/trials/1>; rel=self"}, "number": 1, "status": "active", "labels": {"owner": "test"}, "assignments": [{"parameterName": "cpu", "value": 100}, {"parameterName": "gc", "value": "G1"}], "values": [{"metricName": "cost", "value": 10}]},
+  {"_metadata": {"Link": "<%[1]s/v1/experiments/fixture/trials/2>; rel=self"}, "number": 2, "status": "active", "assignments": [{"parameterName": "cpu", "value": 200.5}, {"parameterName": "gc", "value": "Parallel"}], "values": [{"metricName": "cost", "value": 20}]}
+]}`, srv.URL)
+	})
+	mux.HandleFunc("/v1/experiments/fixture/trials/", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		reported[r.URL.Path] = string(body)
+		mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+	})
+	srv = httptest.NewServer(mux)
+	defer srv.Close()
+
+	// Export the trials as newline-delimited JSON
+	var exported bytes.Buffer
+	get := NewGetTrialsCommand(testConfig(srv.URL+"/"), &NDJSONPrinter{})
+	get.SetArgs([]string{"fixture"})
+	get.SetOut(&exported)
+	get.SetErr(io.Discard)
+	if !assert.NoError(t, get.ExecuteContext(context.Background())) {
+		return
+	}
+
+	// Report the exported trials back to the server
+	report := NewReportTrialsCommand(testConfig(srv.URL+"/"), discardPrinter{})
+	report.SetArgs([]string{"fixture", "-f", "-"})
+	report.SetIn(&exported)
+	report.SetOut(io.Discard)
+	report.SetErr(io.Discard)
+	if assert.NoError(t, report.ExecuteContext(context.Background())) {
+		assert.JSONEq(t, `{"values": [{"metricName": "cost", "value": 10}]}`, reported["/v1/experiments/fixture/trials/1"])
+		assert.JSONEq(t, `{"values": [{"metricName": "cost", "value": 20}]}`, reported["/v1/experiments/fixture/trials/2"])
+	}
+}
+
+func TestReadTrialReports(t *testing.T) {
+	cases := []struct {
+		desc   string
+		format string
+		input  string
+		err    string
+	}{
+		{
+			desc:   "csv",
+			format: "csv",
+			input:  "trial,parameter_cpu,metric_cost,failed\n/v1/experiments/fixture/trials/1,100,10,false\n",
+		},
+		{
+			desc:   "csv unknown column",
+			format: "csv",
+			input:  "trial,metric_cost,metirc_throughput\n/v1/experiments/fixture/trials/1,10,300\n",
+			err:    `unknown column "metirc_throughput"`,
+		},
+		{
+			desc:  "ndjson trial output",
+			input: `{"number": 1, "status": "active", "assignments": [{"parameterName": "cpu", "value": 100}], "values": [{"metricName": "cost", "value": 10}]}`,
+		},
+		{
+			desc:  "ndjson unknown field",
+			input: `{"trial": "/v1/experiments/fixture/trials/1", "metircValues": {"throughput": 300}}`,
+			err:   `row 1: json: unknown field "metircValues"`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			reports, err := readTrialReports(strings.NewReader(c.input), c.format)
+			if c.err != "" {
+				assert.EqualError(t, err, c.err)
+			} else if assert.NoError(t, err) {
+				assert.Len(t, reports, 1)
+			}
+		})
+	}
+}
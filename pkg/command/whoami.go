@@ -50,6 +50,13 @@ func NewWhoAmICommand(cfg Config) *cobra.Command {
 			return err
 		}
 
+		// Warn if the token is not for the configured server
+		if acfg, ok := cfg.(interface{ CheckAudience(*oauth2.Token) error }); ok {
+			if err := acfg.CheckAudience(tok); err != nil {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Warning: this token isn't for this server: %v\n", err)
+			}
+		}
+
 		// Ignore the signature, just extract the claims
 		accessToken, err := jwt.ParseSigned(tok.AccessToken)
 		if err != nil {
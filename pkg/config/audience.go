@@ -0,0 +1,92 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/oauth2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// AudienceMismatchError indicates a token was not issued for the configured server.
+type AudienceMismatchError struct {
+	// The configured server identifier.
+	Server string
+	// The configured issuer.
+	Issuer string
+	// The audiences the token was issued for.
+	TokenAudience []string
+	// The issuer of the token.
+	TokenIssuer string
+}
+
+// Error returns a message describing the mismatch.
+func (e *AudienceMismatchError) Error() string {
+	if e.TokenIssuer != "" && !sameIdentifier(e.TokenIssuer, e.Issuer) {
+		return fmt.Sprintf("token was issued by %q, not the configured issuer %q", e.TokenIssuer, e.Issuer)
+	}
+	return fmt.Sprintf("token is for %s, not the configured server %q", strings.Join(e.TokenAudience, ", "), e.Server)
+}
+
+// CheckAudience verifies the supplied token was issued for the configured server
+// (and by the configured issuer), returning an `*AudienceMismatchError` if it was
+// not. The token is decoded without verifying the signature; tokens which are not
+// JWTs, or which do not contain the relevant claims, are not checked.
+func (cfg *Config) CheckAudience(tok *oauth2.Token) error {
+	if tok == nil || tok.AccessToken == "" {
+		return nil
+	}
+
+	accessToken, err := jwt.ParseSigned(tok.AccessToken)
+	if err != nil {
+		return nil
+	}
+
+	claims := jwt.Claims{}
+	if err := accessToken.UnsafeClaimsWithoutVerification(&claims); err != nil {
+		return nil
+	}
+
+	mismatch := &AudienceMismatchError{
+		Server:        cfg.Address(),
+		Issuer:        cfg.Issuer,
+		TokenAudience: claims.Audience,
+		TokenIssuer:   claims.Issuer,
+	}
+
+	if claims.Issuer != "" && cfg.Issuer != "" && !sameIdentifier(claims.Issuer, cfg.Issuer) {
+		return mismatch
+	}
+
+	if len(claims.Audience) > 0 && cfg.Address() != "" {
+		for _, aud := range claims.Audience {
+			if sameIdentifier(aud, cfg.Address()) {
+				return nil
+			}
+		}
+		return mismatch
+	}
+
+	return nil
+}
+
+// sameIdentifier compares server identifiers, ignoring trailing slashes.
+func sameIdentifier(a, b string) bool {
+	return strings.TrimRight(a, "/") == strings.TrimRight(b, "/")
+}
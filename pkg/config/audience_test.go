@@ -0,0 +1,89 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestConfig_CheckAudience(t *testing.T) {
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: []byte("0123456789abcdef")}, nil)
+	require.NoError(t, err)
+
+	cfg := &Config{Server: "https://api.example.com/", Issuer: "https://auth.example.com/"}
+
+	cases := []struct {
+		desc     string
+		claims   map[string]interface{}
+		token    string
+		mismatch bool
+	}{
+		{
+			desc:   "matching audience",
+			claims: map[string]interface{}{"aud": "https://api.example.com/", "iss": "https://auth.example.com/"},
+		},
+		{
+			desc:   "matching audience list without trailing slash",
+			claims: map[string]interface{}{"aud": []string{"https://other.example.com", "https://api.example.com"}},
+		},
+		{
+			desc:     "mismatched audience",
+			claims:   map[string]interface{}{"aud": "https://api.other.example.com/"},
+			mismatch: true,
+		},
+		{
+			desc:     "mismatched issuer",
+			claims:   map[string]interface{}{"aud": "https://api.example.com/", "iss": "https://auth.other.example.com/"},
+			mismatch: true,
+		},
+		{
+			desc:   "no claims",
+			claims: map[string]interface{}{"sub": "test"},
+		},
+		{
+			desc:  "non-JWT",
+			token: "opaque-access-token",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			tok := &oauth2.Token{AccessToken: c.token}
+			if c.claims != nil {
+				tok.AccessToken, err = jwt.Signed(signer).Claims(c.claims).CompactSerialize()
+				require.NoError(t, err)
+			}
+
+			err := cfg.CheckAudience(tok)
+			if !c.mismatch {
+				assert.NoError(t, err)
+				return
+			}
+
+			var mismatchErr *AudienceMismatchError
+			if assert.True(t, errors.As(err, &mismatchErr)) {
+				assert.Equal(t, cfg.Server, mismatchErr.Server)
+			}
+		})
+	}
+}
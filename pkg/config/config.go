@@ -21,7 +21,11 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"sort"
+	"strings"
+	"sync"
 
+	"github.com/thestormforge/optimize-go/pkg/api"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/clientcredentials"
 )
@@ -43,6 +47,30 @@ type Config struct {
 	// A hard-coded bearer token for debugging, the token will not be refreshed
 	// so the caller is responsible for providing a valid token.
 	Token string `json:"-" yaml:"-" env:"STORMFORGE_TOKEN"`
+	// Optional credentials for specific audiences, keyed by the audience. Requests
+	// to addresses prefixed by an audience are authorized using that audience's
+	// credentials instead of the top-level credentials.
+	Audiences map[string]Credential `json:"audiences,omitempty" yaml:"audiences,omitempty"`
+	// The identifier of the account to use for users belonging to multiple accounts.
+	// The account is sent to the API server using the `AccountHeader`.
+	Account string `json:"account,omitempty" yaml:"account,omitempty" env:"STORMFORGE_ACCOUNT"`
+	// Optional client-side preferences.
+	Preferences Preferences `json:"preferences,omitempty" yaml:"preferences,omitempty"`
+}
+
+// AccountHeader is the request header used to select an account.
+const AccountHeader = "StormForge-Account"
+
+// Credential is used to obtain tokens for a specific audience.
+type Credential struct {
+	// The client ID used to obtain tokens via a client credentials grant.
+	ClientID string `json:"client_id,omitempty" yaml:"client_id,omitempty"`
+	// The client secret used to obtain tokens via a client credentials grant.
+	ClientSecret string `json:"client_secret,omitempty" yaml:"client_secret,omitempty"`
+	// The list of scopes to request during token exchanges.
+	Scopes []string `json:"scopes,omitempty" yaml:"scopes,omitempty"`
+	// A hard-coded bearer token for debugging.
+	Token string `json:"-" yaml:"-"`
 }
 
 // Address returns the API server address. The canonical value will be slash-terminated,
@@ -51,59 +79,411 @@ func (cfg *Config) Address() string {
 	return cfg.Server
 }
 
+// ExperimentsURL returns the resolved base URL of the experiments API.
+func (cfg *Config) ExperimentsURL() (string, error) {
+	return cfg.endpoint("v1/experiments/")
+}
+
+// ApplicationsURL returns the resolved base URL of the applications API.
+func (cfg *Config) ApplicationsURL() (string, error) {
+	return cfg.endpoint("v2/applications/")
+}
+
+// AccountsURL returns the resolved base URL of the accounts API.
+func (cfg *Config) AccountsURL() (string, error) {
+	return cfg.endpoint("v1/accounts/")
+}
+
+// endpoint resolves an API endpoint relative to the server address.
+func (cfg *Config) endpoint(ep string) (string, error) {
+	u, err := joinURL(cfg.Address(), ep)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("server address must be an absolute URL: %q", cfg.Address())
+	}
+	return u.String(), nil
+}
+
+// TokenURL returns the resolved URL of the authorization server's token endpoint.
+func (cfg *Config) TokenURL() (string, error) {
+	u, err := joinURL(cfg.Issuer, "oauth/token")
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "https" {
+		return "", fmt.Errorf("issuer is required and must be HTTPS")
+	}
+	return u.String(), nil
+}
+
+// ResolvedServer is a fully resolved view of the server configuration, intended
+// for diagnostic output. Secrets are never included.
+type ResolvedServer struct {
+	Identifier      string   `json:"identifier" yaml:"identifier"`
+	Account         string   `json:"account,omitempty" yaml:"account,omitempty"`
+	Issuer          string   `json:"issuer,omitempty" yaml:"issuer,omitempty"`
+	TokenURL        string   `json:"token_url,omitempty" yaml:"token_url,omitempty"`
+	ExperimentsURL  string   `json:"experiments_url" yaml:"experiments_url"`
+	ApplicationsURL string   `json:"applications_url" yaml:"applications_url"`
+	AccountsURL     string   `json:"accounts_url" yaml:"accounts_url"`
+	ClientID        string   `json:"client_id,omitempty" yaml:"client_id,omitempty"`
+	ClientSecret    string   `json:"client_secret,omitempty" yaml:"client_secret,omitempty"`
+	Token           string   `json:"token,omitempty" yaml:"token,omitempty"`
+	Audiences       []string `json:"audiences,omitempty" yaml:"audiences,omitempty"`
+}
+
+// ResolveServer computes all of the endpoints for the current configuration.
+func (cfg *Config) ResolveServer() (*ResolvedServer, error) {
+	rs := &ResolvedServer{
+		Identifier:   cfg.Address(),
+		Account:      cfg.Account,
+		Issuer:       cfg.Issuer,
+		ClientID:     cfg.ClientID,
+		ClientSecret: redact(cfg.ClientSecret),
+		Token:        redact(cfg.Token),
+	}
+
+	var err error
+	if rs.ExperimentsURL, err = cfg.ExperimentsURL(); err != nil {
+		return nil, err
+	}
+	if rs.ApplicationsURL, err = cfg.ApplicationsURL(); err != nil {
+		return nil, err
+	}
+	if rs.AccountsURL, err = cfg.AccountsURL(); err != nil {
+		return nil, err
+	}
+
+	// Only report the token endpoint if there is an issuer to resolve it against
+	if cfg.Issuer != "" {
+		if rs.TokenURL, err = cfg.TokenURL(); err != nil {
+			return nil, err
+		}
+	}
+
+	for aud := range cfg.Audiences {
+		rs.Audiences = append(rs.Audiences, aud)
+	}
+	sort.Strings(rs.Audiences)
+
+	return rs, nil
+}
+
+// Redacted returns a copy of the configuration with all secrets hidden.
+func (cfg *Config) Redacted() *Config {
+	r := *cfg
+	r.ClientSecret = redact(r.ClientSecret)
+	r.Token = redact(r.Token)
+	if cfg.Audiences != nil {
+		r.Audiences = make(map[string]Credential, len(cfg.Audiences))
+		for aud, cred := range cfg.Audiences {
+			cred.ClientSecret = redact(cred.ClientSecret)
+			cred.Token = redact(cred.Token)
+			r.Audiences[aud] = cred
+		}
+	}
+	return &r
+}
+
+// redact hides non-empty secret values.
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return "REDACTED"
+}
+
+// Validate checks the configuration for invalid values.
+func (cfg *Config) Validate() error {
+	if strings.ContainsAny(cfg.Account, " \t\r\n/") {
+		return fmt.Errorf("invalid account %q, must not contain whitespace or slashes", cfg.Account)
+	}
+
+	if err := (&Credential{ClientID: cfg.ClientID, Token: cfg.Token}).validate(); err != nil {
+		return err
+	}
+	auds := make([]string, 0, len(cfg.Audiences))
+	for aud := range cfg.Audiences {
+		auds = append(auds, aud)
+	}
+	sort.Strings(auds)
+	for _, aud := range auds {
+		cred := cfg.Audiences[aud]
+		if err := cred.validate(); err != nil {
+			return fmt.Errorf("invalid credential for audience %q: %w", aud, err)
+		}
+	}
+
+	return cfg.Preferences.validate()
+}
+
+// validate checks that the credential is not ambiguous.
+func (cred *Credential) validate() error {
+	if cred.Token != "" && cred.ClientID != "" {
+		return fmt.Errorf("conflicting credentials, only one of an access token or a client ID may be specified")
+	}
+	return nil
+}
+
 // Transport wraps the supplied round tripper (presumably the `http.DefaultTransport`)
 // based on the current state of the configuration.
 func (cfg *Config) Transport(ctx context.Context, base http.RoundTripper) http.RoundTripper {
 	transport := base
 
-	// Add an authorization transport if there is a token source available
+	// Add an authorization transport if there is a token source available, requests
+	// which are already authorized (e.g. using `ClientOptions`) are left unchanged
 	if src := cfg.TokenSource(ctx); src != nil {
-		// TODO This needs to check the URL prefix before sending a token along...
-		transport = &oauth2.Transport{Source: src, Base: transport}
+		transport = &defaultAuthorizationTransport{
+			Transport: oauth2.Transport{Source: src, Base: transport},
+			Server:    cfg.Address(),
+		}
+	}
+
+	// Requests for specific audiences use their own authorization
+	if len(cfg.Audiences) > 0 {
+		at := &audienceTransport{
+			Default:   transport,
+			Audiences: make(map[string]http.RoundTripper, len(cfg.Audiences)),
+		}
+		for aud, cred := range cfg.Audiences {
+			if src := cfg.refreshableTokenSource(ctx, aud, cred); src != nil {
+				at.Audiences[aud] = &refreshTransport{Source: src, Base: base}
+			}
+		}
+		transport = at
+	}
+
+	// Select the account on requests to the API server
+	if cfg.Account != "" {
+		transport = &accountTransport{
+			Account: cfg.Account,
+			Server:  cfg.Address(),
+			Base:    transport,
+		}
 	}
 
 	return transport
 }
 
+// ClientOptions returns the options used to create API clients for this configuration.
+// The clients authorize requests themselves so rejected tokens can be refreshed.
+func (cfg *Config) ClientOptions(ctx context.Context) []api.ClientOption {
+	var opts []api.ClientOption
+	if src := cfg.refreshableTokenSource(ctx, cfg.Server, cfg.Credential("")); src != nil {
+		opts = append(opts, api.WithTokenSource(src))
+	}
+	return opts
+}
+
 // TokenSource returns a new source for obtaining tokens. The token source may be
 // nil if there is insufficient configuration available, typically this would
 // indicate the API server does not require authorization.
 func (cfg *Config) TokenSource(ctx context.Context) oauth2.TokenSource {
+	return cfg.tokenSource(ctx, cfg.Server, Credential{
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.ClientSecret,
+		Scopes:       cfg.Scopes,
+		Token:        cfg.Token,
+	})
+}
+
+// TokenSourceForAudience returns a new source for obtaining tokens for the specified
+// audience. If there are no credentials specific to the audience, the default token
+// source is returned.
+func (cfg *Config) TokenSourceForAudience(ctx context.Context, audience string) oauth2.TokenSource {
+	if cred, ok := cfg.Audiences[audience]; ok {
+		return cfg.tokenSource(ctx, audience, cred)
+	}
+	return cfg.TokenSource(ctx)
+}
+
+// Credential returns the credentials used for the specified audience.
+func (cfg *Config) Credential(audience string) Credential {
+	if cred, ok := cfg.Audiences[audience]; ok {
+		return cred
+	}
+	return Credential{
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.ClientSecret,
+		Scopes:       cfg.Scopes,
+		Token:        cfg.Token,
+	}
+}
+
+func (cfg *Config) tokenSource(ctx context.Context, audience string, cred Credential) oauth2.TokenSource {
+	if src := cfg.refreshableTokenSource(ctx, audience, cred); src != nil {
+		return oauth2.ReuseTokenSource(nil, src)
+	}
+	return nil
+}
+
+// refreshableTokenSource returns a source which obtains a new token each time it is called,
+// callers are responsible for caching the tokens.
+func (cfg *Config) refreshableTokenSource(ctx context.Context, audience string, cred Credential) oauth2.TokenSource {
 	switch {
 
-	case cfg.Token != "":
+	case cred.Token != "":
 		return oauth2.StaticTokenSource(&oauth2.Token{
-			AccessToken: cfg.Token,
+			AccessToken: cred.Token,
 		})
 
-	case cfg.ClientID != "":
-		tokenURL, err := url.Parse(cfg.Issuer)
+	case cred.ClientID != "":
+		cc, err := cfg.clientCredentials(audience, cred)
 		if err != nil {
 			return &errorTokenSource{err: err}
 		}
-		if tokenURL.Scheme != "https" {
-			return &errorTokenSource{err: fmt.Errorf("issuer is required and must be HTTPS")}
+		return tokenSourceFunc(func() (*oauth2.Token, error) { return cc.Token(ctx) })
+
+	default:
+		return nil
+	}
+}
+
+// clientCredentials returns the configuration for a client credentials grant.
+func (cfg *Config) clientCredentials(audience string, cred Credential) (*clientcredentials.Config, error) {
+	tokenURL, err := cfg.TokenURL()
+	if err != nil {
+		return nil, err
+	}
+
+	return &clientcredentials.Config{
+		ClientID:       cred.ClientID,
+		ClientSecret:   cred.ClientSecret,
+		TokenURL:       tokenURL,
+		Scopes:         cred.Scopes,
+		EndpointParams: url.Values{"audience": []string{audience}},
+		AuthStyle:      oauth2.AuthStyleInParams,
+	}, nil
+}
+
+// audienceTransport selects a round tripper based on the longest audience
+// which prefixes the request URL.
+type audienceTransport struct {
+	Default   http.RoundTripper
+	Audiences map[string]http.RoundTripper
+}
+
+// RoundTrip delegates to the round tripper for the request's audience.
+func (t *audienceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport, match := t.Default, ""
+	for aud, rt := range t.Audiences {
+		if hasURLPrefix(req.URL, aud) && len(aud) > len(match) {
+			transport, match = rt, aud
 		}
-		tokenURL, err = tokenURL.Parse("oauth/token")
+	}
+
+	if transport == nil {
+		return http.DefaultTransport.RoundTrip(req)
+	}
+	return transport.RoundTrip(req)
+}
+
+// refreshTransport authorizes requests using tokens from a source which obtains a new
+// token each time it is called. Tokens are reused until they expire or the server
+// rejects them, rejected requests are retried once using a new token.
+type refreshTransport struct {
+	Source oauth2.TokenSource
+	Base   http.RoundTripper
+
+	mu  sync.Mutex
+	src oauth2.TokenSource
+}
+
+// RoundTrip authorizes the request, refreshing the token if it is rejected.
+func (t *refreshTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	src := t.tokenSource(nil)
+	resp, err := (&oauth2.Transport{Source: src, Base: t.Base}).RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || (req.Body != nil && req.GetBody == nil) {
+		return resp, err
+	}
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
 		if err != nil {
-			return &errorTokenSource{err: err}
+			return resp, nil
 		}
+		retry.Body = body
+	}
+
+	_ = resp.Body.Close()
+	return (&oauth2.Transport{Source: t.tokenSource(src), Base: t.Base}).RoundTrip(retry)
+}
+
+// tokenSource returns the caching token source, the supplied source is discarded if it is current.
+func (t *refreshTransport) tokenSource(rejected oauth2.TokenSource) oauth2.TokenSource {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.src == nil || t.src == rejected {
+		t.src = oauth2.ReuseTokenSource(nil, t.Source)
+	}
+	return t.src
+}
+
+// defaultAuthorizationTransport authorizes requests to the API server which are not already authorized.
+type defaultAuthorizationTransport struct {
+	oauth2.Transport
+	Server string
+}
 
-		cc := clientcredentials.Config{
-			ClientID:       cfg.ClientID,
-			ClientSecret:   cfg.ClientSecret,
-			TokenURL:       tokenURL.String(),
-			Scopes:         cfg.Scopes,
-			EndpointParams: url.Values{"audience": []string{cfg.Server}},
-			AuthStyle:      oauth2.AuthStyleInParams,
+// RoundTrip authorizes the request only if it is prefixed by the server address and
+// does not already have an authorization header.
+func (t *defaultAuthorizationTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Authorization") != "" || !hasURLPrefix(req.URL, t.Server) {
+		if t.Base == nil {
+			return http.DefaultTransport.RoundTrip(req)
 		}
-		return cc.TokenSource(ctx)
+		return t.Base.RoundTrip(req)
+	}
+	return t.Transport.RoundTrip(req)
+}
 
-	default:
-		return nil
+// accountTransport adds the account header to requests for the API server.
+type accountTransport struct {
+	Account string
+	Server  string
+	Base    http.RoundTripper
+}
+
+// RoundTrip adds the account header to requests prefixed by the server address.
+func (t *accountTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if hasURLPrefix(req.URL, t.Server) {
+		// Do not modify the original request
+		req = req.Clone(req.Context())
+		req.Header.Set(AccountHeader, t.Account)
+	}
+
+	if t.Base == nil {
+		return http.DefaultTransport.RoundTrip(req)
 	}
+	return t.Base.RoundTrip(req)
 }
 
+// hasURLPrefix checks if the URL is prefixed by the supplied address. The scheme and
+// host must match exactly and the path must match on a segment boundary, e.g. the
+// address "https://example.com/api" prefixes "https://example.com/api/v1" but not
+// "https://example.com/apiv1" or "https://example.com.evil.com/api".
+func hasURLPrefix(u *url.URL, prefix string) bool {
+	p, err := url.Parse(prefix)
+	if err != nil || p.Host == "" {
+		return false
+	}
+	if !strings.EqualFold(u.Scheme, p.Scheme) || !strings.EqualFold(u.Host, p.Host) {
+		return false
+	}
+
+	path := strings.TrimSuffix(p.EscapedPath(), "/")
+	return path == "" || u.EscapedPath() == path || strings.HasPrefix(u.EscapedPath(), path+"/")
+}
+
+// tokenSourceFunc adapts a function to the token source interface.
+type tokenSourceFunc func() (*oauth2.Token, error)
+
+func (f tokenSourceFunc) Token() (*oauth2.Token, error) { return f() }
+
 // errorTokenSource is a TokenSource that always returns an error.
 type errorTokenSource struct {
 	err error
@@ -0,0 +1,389 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thestormforge/optimize-go/pkg/api"
+	"golang.org/x/oauth2"
+)
+
+func TestConfig_Endpoints(t *testing.T) {
+	cases := []struct {
+		desc         string
+		server       string
+		experiments  string
+		applications string
+		accounts     string
+		err          bool
+	}{
+		{
+			desc:         "default",
+			server:       "https://api.stormforge.io/",
+			experiments:  "https://api.stormforge.io/v1/experiments/",
+			applications: "https://api.stormforge.io/v2/applications/",
+			accounts:     "https://api.stormforge.io/v1/accounts/",
+		},
+		{
+			desc:         "no trailing slash",
+			server:       "https://example.com/stormforge",
+			experiments:  "https://example.com/stormforge/v1/experiments/",
+			applications: "https://example.com/stormforge/v2/applications/",
+			accounts:     "https://example.com/stormforge/v1/accounts/",
+		},
+		{
+			desc:   "relative",
+			server: "api.stormforge.io",
+			err:    true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			cfg := &Config{Server: c.server}
+
+			experiments, err := cfg.ExperimentsURL()
+			if c.err {
+				assert.Error(t, err)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, c.experiments, experiments)
+			}
+
+			applications, err := cfg.ApplicationsURL()
+			if assert.NoError(t, err) {
+				assert.Equal(t, c.applications, applications)
+			}
+
+			accounts, err := cfg.AccountsURL()
+			if assert.NoError(t, err) {
+				assert.Equal(t, c.accounts, accounts)
+			}
+		})
+	}
+}
+
+func TestConfig_Transport_Audiences(t *testing.T) {
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+	}))
+	defer srv.Close()
+
+	cfg := &Config{
+		Server: srv.URL + "/",
+		Token:  "default",
+		Audiences: map[string]Credential{
+			srv.URL + "/v2/":              {Token: "v2"},
+			srv.URL + "/v2/applications/": {Token: "applications"},
+		},
+	}
+	client := http.Client{Transport: cfg.Transport(context.Background(), http.DefaultTransport)}
+
+	cases := []struct {
+		desc string
+		path string
+		auth string
+	}{
+		{desc: "default", path: "/v1/experiments/", auth: "Bearer default"},
+		{desc: "audience", path: "/v2/clusters/", auth: "Bearer v2"},
+		{desc: "longest audience", path: "/v2/applications/foo", auth: "Bearer applications"},
+		{desc: "partial path segment", path: "/v2/applicationsfoo", auth: "Bearer v2"},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			resp, err := client.Get(srv.URL + c.path)
+			if assert.NoError(t, err) {
+				resp.Body.Close()
+				assert.Equal(t, c.auth, auth)
+			}
+		})
+	}
+
+	// Audience credentials must not leak to hosts which only share a string prefix
+	var leaked string
+	transport := &audienceTransport{
+		Audiences: map[string]http.RoundTripper{
+			"https://api.stormforge.io/": roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				leaked = "audience"
+				return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+			}),
+		},
+		Default: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			leaked = "default"
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+		}),
+	}
+	req, _ := http.NewRequest(http.MethodGet, "https://api.stormforge.io.evil.com/v2/", nil)
+	if _, err := transport.RoundTrip(req); assert.NoError(t, err) {
+		assert.Equal(t, "default", leaked)
+	}
+}
+
+func TestConfig_Transport_AudienceRefresh(t *testing.T) {
+	var issued int
+	issuer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		issued++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"access_token": "token-%d", "token_type": "bearer", "expires_in": 3600}`, issued)
+	}))
+	defer issuer.Close()
+
+	var auth []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = append(auth, r.Header.Get("Authorization"))
+		if r.Header.Get("Authorization") == "Bearer token-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+
+	cfg := &Config{
+		Server: srv.URL + "/",
+		Issuer: issuer.URL + "/",
+		Audiences: map[string]Credential{
+			srv.URL + "/v2/": {ClientID: "client", ClientSecret: "secret"},
+		},
+	}
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, issuer.Client())
+	client := http.Client{Transport: cfg.Transport(ctx, http.DefaultTransport)}
+
+	// A rejected token is replaced and the new token is reused by subsequent requests
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(srv.URL + "/v2/applications/")
+		if assert.NoError(t, err) {
+			resp.Body.Close()
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+		}
+	}
+	assert.Equal(t, []string{"Bearer token-1", "Bearer token-2", "Bearer token-2"}, auth)
+	assert.Equal(t, 2, issued)
+}
+
+func TestConfig_Transport_Server(t *testing.T) {
+	var auth string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+	})
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	other := httptest.NewServer(handler)
+	defer other.Close()
+
+	cfg := &Config{Server: srv.URL + "/", Token: "default"}
+	client := http.Client{Transport: cfg.Transport(context.Background(), http.DefaultTransport)}
+
+	cases := []struct {
+		desc string
+		url  string
+		auth string
+	}{
+		{desc: "server", url: srv.URL + "/v1/experiments/", auth: "Bearer default"},
+		{desc: "other server", url: other.URL + "/v1/experiments/"},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			auth = ""
+			resp, err := client.Get(c.url)
+			if assert.NoError(t, err) {
+				resp.Body.Close()
+				assert.Equal(t, c.auth, auth)
+			}
+		})
+	}
+}
+
+func TestConfig_Transport_Account(t *testing.T) {
+	var account string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		account = r.Header.Get(AccountHeader)
+	}))
+	defer srv.Close()
+
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		account = r.Header.Get(AccountHeader)
+	}))
+	defer other.Close()
+
+	cases := []struct {
+		desc     string
+		account  string
+		url      string
+		expected string
+	}{
+		{desc: "no account", url: srv.URL + "/v1/experiments/"},
+		{desc: "account", account: "acme", url: srv.URL + "/v1/experiments/", expected: "acme"},
+		{desc: "other server", account: "acme", url: other.URL + "/v1/experiments/"},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			account = ""
+			cfg := &Config{Server: srv.URL + "/", Account: c.account}
+			if !assert.NoError(t, cfg.Validate()) {
+				return
+			}
+
+			client := http.Client{Transport: cfg.Transport(context.Background(), http.DefaultTransport)}
+			resp, err := client.Get(c.url)
+			if assert.NoError(t, err) {
+				resp.Body.Close()
+				assert.Equal(t, c.expected, account)
+			}
+		})
+	}
+
+	// The account must not leak to hosts which only share a string prefix with the server
+	var leaked string
+	transport := &accountTransport{
+		Account: "acme",
+		Server:  "https://api.stormforge.io/",
+		Base: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			leaked = req.Header.Get(AccountHeader)
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+		}),
+	}
+	req, _ := http.NewRequest(http.MethodGet, "https://api.stormforge.io.evil.com/v1/experiments/", nil)
+	if _, err := transport.RoundTrip(req); assert.NoError(t, err) {
+		assert.Empty(t, leaked)
+	}
+
+	assert.Error(t, (&Config{Account: "acme corp"}).Validate())
+	assert.Error(t, (&Config{Account: "acme/corp"}).Validate())
+}
+
+func TestConfig_ClientOptions(t *testing.T) {
+	var issued int
+	issuer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		issued++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"access_token": "token-%d", "token_type": "bearer"}`, issued)
+	}))
+	defer issuer.Close()
+
+	var auth []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = append(auth, r.Header.Get("Authorization"))
+		if r.Header.Get("Authorization") == "Bearer token-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+
+	cfg := &Config{
+		Server:       srv.URL + "/",
+		Issuer:       issuer.URL + "/",
+		ClientID:     "client",
+		ClientSecret: "secret",
+	}
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, issuer.Client())
+
+	// The client's token must not be replaced by the default authorization of the transport
+	client, err := api.NewClient(cfg.Address(), cfg.Transport(ctx, http.DefaultTransport), cfg.ClientOptions(ctx)...)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/v1/experiments/", nil)
+	resp, _, err := client.Do(ctx, req)
+	if assert.NoError(t, err) {
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, []string{"Bearer token-1", "Bearer token-2"}, auth)
+	}
+}
+
+func TestConfig_Validate_Credentials(t *testing.T) {
+	cases := []struct {
+		desc string
+		cfg  Config
+		err  string
+	}{
+		{
+			desc: "client credentials",
+			cfg:  Config{ClientID: "client", ClientSecret: "secret"},
+		},
+		{
+			desc: "access token",
+			cfg:  Config{Token: "token"},
+		},
+		{
+			desc: "both",
+			cfg:  Config{ClientID: "client", Token: "token"},
+			err:  "conflicting credentials, only one of an access token or a client ID may be specified",
+		},
+		{
+			desc: "audience with both",
+			cfg: Config{
+				Token: "token",
+				Audiences: map[string]Credential{
+					"https://a.example.com/": {Token: "token"},
+					"https://b.example.com/": {ClientID: "client", Token: "token"},
+				},
+			},
+			err: `invalid credential for audience "https://b.example.com/": conflicting credentials, only one of an access token or a client ID may be specified`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			err := c.cfg.Validate()
+			if c.err != "" {
+				assert.EqualError(t, err, c.err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestHasURLPrefix(t *testing.T) {
+	cases := []struct {
+		desc     string
+		url      string
+		prefix   string
+		expected bool
+	}{
+		{desc: "root", url: "https://api.stormforge.io/v1/experiments/", prefix: "https://api.stormforge.io/", expected: true},
+		{desc: "no trailing slash", url: "https://api.stormforge.io/v1/experiments/", prefix: "https://api.stormforge.io", expected: true},
+		{desc: "path segment", url: "https://example.com/api/v1", prefix: "https://example.com/api", expected: true},
+		{desc: "exact path", url: "https://example.com/api", prefix: "https://example.com/api/", expected: true},
+		{desc: "partial path segment", url: "https://example.com/apiv1", prefix: "https://example.com/api"},
+		{desc: "host suffix", url: "https://api.stormforge.io.evil.com/v1/experiments/", prefix: "https://api.stormforge.io/"},
+		{desc: "userinfo", url: "https://api.stormforge.io@evil.com/v1/experiments/", prefix: "https://api.stormforge.io/"},
+		{desc: "port", url: "https://api.stormforge.io:8443/", prefix: "https://api.stormforge.io/"},
+		{desc: "scheme", url: "http://api.stormforge.io/", prefix: "https://api.stormforge.io/"},
+		{desc: "relative prefix", url: "https://api.stormforge.io/", prefix: "api.stormforge.io"},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			u, err := url.Parse(c.url)
+			if assert.NoError(t, err) {
+				assert.Equal(t, c.expected, hasURLPrefix(u, c.prefix))
+			}
+		})
+	}
+}
+
+// roundTripperFunc adapts a function to the round tripper interface.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
@@ -0,0 +1,60 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"net/url"
+	"strings"
+)
+
+const (
+	// EnvironmentProduction is the name of the production environment.
+	EnvironmentProduction = "production"
+	// EnvironmentStaging is the name of the staging environment.
+	EnvironmentStaging = "staging"
+	// EnvironmentDevelopment is the name of the development environment.
+	EnvironmentDevelopment = "development"
+)
+
+// environmentDomains maps known domains to the environment they host.
+var environmentDomains = map[string]string{
+	"stormforge.io":  EnvironmentProduction,
+	"stormforge.dev": EnvironmentStaging,
+	"gramlabs.dev":   EnvironmentDevelopment,
+}
+
+// DetectEnvironment returns the name of the environment hosting the supplied server
+// identifier, an empty string is returned if the environment is not known.
+func DetectEnvironment(identifier string) string {
+	u, err := url.Parse(identifier)
+	if err != nil {
+		return ""
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	for domain, env := range environmentDomains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return env
+		}
+	}
+	return ""
+}
+
+// Environment returns the name of the environment hosting the configured server.
+func (cfg *Config) Environment() string {
+	return DetectEnvironment(cfg.Address())
+}
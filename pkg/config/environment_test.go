@@ -0,0 +1,69 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectEnvironment(t *testing.T) {
+	cases := []struct {
+		desc       string
+		identifier string
+		expected   string
+	}{
+		{
+			desc:       "production",
+			identifier: "https://api.stormforge.io/",
+			expected:   EnvironmentProduction,
+		},
+		{
+			desc:       "staging",
+			identifier: "https://api.stormforge.dev/",
+			expected:   EnvironmentStaging,
+		},
+		{
+			desc:       "development",
+			identifier: "https://api.gramlabs.dev/",
+			expected:   EnvironmentDevelopment,
+		},
+		{
+			desc:       "bare domain",
+			identifier: "https://stormforge.io",
+			expected:   EnvironmentProduction,
+		},
+		{
+			desc:       "unknown",
+			identifier: "https://api.example.com/",
+		},
+		{
+			desc:       "domain suffix",
+			identifier: "https://notstormforge.io/",
+		},
+		{
+			desc:       "invalid",
+			identifier: "://",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			assert.Equal(t, c.expected, DetectEnvironment(c.identifier))
+		})
+	}
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/thestormforge/optimize-go/pkg/api"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// DefaultKeySetMaxAge is how long a key set is cached when the server does not say.
+const DefaultKeySetMaxAge = time.Hour
+
+// JSONWebKeySetURL returns the resolved URL of the authorization server's key set.
+func (cfg *Config) JSONWebKeySetURL() (string, error) {
+	u, err := joinURL(cfg.Issuer, ".well-known/jwks.json")
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "https" {
+		return "", fmt.Errorf("issuer is required and must be HTTPS")
+	}
+	return u.String(), nil
+}
+
+// KeySet fetches and caches a JSON Web Key Set so token signatures can be verified
+// locally. The key set is cached according to the response cache headers and is
+// fetched again whenever a token is signed with an unknown key.
+type KeySet struct {
+	// The location of the key set.
+	URL string
+	// The client used to fetch the key set, defaults to `http.DefaultClient`.
+	Client *http.Client
+	// The clock used to expire the cached key set, defaults to the system clock.
+	Clock api.Clock
+
+	mu      sync.Mutex
+	keys    jose.JSONWebKeySet
+	expires time.Time
+}
+
+// NewKeySet returns a key set for the configured authorization server.
+func (cfg *Config) NewKeySet() (*KeySet, error) {
+	u, err := cfg.JSONWebKeySetURL()
+	if err != nil {
+		return nil, err
+	}
+	return &KeySet{URL: u}, nil
+}
+
+// Verify checks the signature of the supplied token and decodes its claims into
+// the destination values.
+func (ks *KeySet) Verify(ctx context.Context, token string, dest ...interface{}) error {
+	tok, err := jwt.ParseSigned(token)
+	if err != nil {
+		return err
+	}
+	if len(tok.Headers) == 0 {
+		return fmt.Errorf("token is missing a header")
+	}
+
+	key, err := ks.key(ctx, tok.Headers[0].KeyID)
+	if err != nil {
+		return err
+	}
+	return tok.Claims(key, dest...)
+}
+
+// key returns the identified key, fetching the key set if necessary.
+func (ks *KeySet) key(ctx context.Context, kid string) (*jose.JSONWebKey, error) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	// Check the cache first, an unknown key may indicate the keys were rotated
+	if ks.now().Before(ks.expires) {
+		if keys := ks.keys.Key(kid); len(keys) > 0 {
+			return &keys[0], nil
+		}
+	}
+
+	if err := ks.fetch(ctx); err != nil {
+		return nil, err
+	}
+
+	if keys := ks.keys.Key(kid); len(keys) > 0 {
+		return &keys[0], nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// fetch retrieves the key set from the server.
+func (ks *KeySet) fetch(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ks.URL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	client := ks.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unable to fetch key set: %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	keys := jose.JSONWebKeySet{}
+	if err := json.Unmarshal(body, &keys); err != nil {
+		return err
+	}
+
+	ks.keys = keys
+	ks.expires = ks.now().Add(maxAge(resp.Header))
+	return nil
+}
+
+// now returns the current time according to the configured clock.
+func (ks *KeySet) now() time.Time {
+	if ks.Clock == nil {
+		return api.SystemClock.Now()
+	}
+	return ks.Clock.Now()
+}
+
+// maxAge returns how long a response can be cached for.
+func maxAge(h http.Header) time.Duration {
+	for _, directive := range strings.Split(h.Get("Cache-Control"), ",") {
+		directive = strings.TrimSpace(strings.ToLower(directive))
+		switch {
+		case directive == "no-cache" || directive == "no-store":
+			return 0
+		case strings.HasPrefix(directive, "max-age="):
+			if seconds, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age=")); err == nil && seconds >= 0 {
+				return time.Duration(seconds) * time.Second
+			}
+		}
+	}
+	return DefaultKeySetMaxAge
+}
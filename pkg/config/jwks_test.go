@@ -0,0 +1,110 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thestormforge/optimize-go/pkg/api"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestKeySet_Verify(t *testing.T) {
+	newKey := func(kid string) (jose.JSONWebKey, jose.Signer) {
+		pk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.ES256, Key: jose.JSONWebKey{Key: pk, KeyID: kid}}, nil)
+		require.NoError(t, err)
+		return jose.JSONWebKey{Key: &pk.PublicKey, KeyID: kid, Algorithm: string(jose.ES256), Use: "sig"}, signer
+	}
+	sign := func(signer jose.Signer, sub string) string {
+		tok, err := jwt.Signed(signer).Claims(jwt.Claims{Subject: sub}).CompactSerialize()
+		require.NoError(t, err)
+		return tok
+	}
+
+	key1, signer1 := newKey("key-1")
+	key2, signer2 := newKey("key-2")
+	_, unknownSigner := newKey("key-3")
+
+	var fetches int
+	keys := jose.JSONWebKeySet{Keys: []jose.JSONWebKey{key1}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=3600")
+		_ = json.NewEncoder(w).Encode(keys)
+	}))
+	defer srv.Close()
+
+	now := time.Date(2022, time.March, 1, 12, 0, 0, 0, time.UTC)
+	ks := &KeySet{URL: srv.URL + "/.well-known/jwks.json", Clock: api.FixedClock(now)}
+	ctx := context.Background()
+
+	// Valid signatures are verified, the key set is only fetched once
+	for i := 0; i < 2; i++ {
+		claims := jwt.Claims{}
+		if assert.NoError(t, ks.Verify(ctx, sign(signer1, "test"), &claims)) {
+			assert.Equal(t, "test", claims.Subject)
+		}
+	}
+	assert.Equal(t, 1, fetches)
+
+	// Tampering with the payload invalidates the signature
+	parts := strings.Split(sign(signer1, "test"), ".")
+	forged := strings.Split(sign(unknownSigner, "admin"), ".")
+	assert.Error(t, ks.Verify(ctx, parts[0]+"."+forged[1]+"."+parts[2]))
+
+	// Rotated keys are fetched on demand
+	keys.Keys = append(keys.Keys, key2)
+	assert.NoError(t, ks.Verify(ctx, sign(signer2, "rotated")))
+	assert.Equal(t, 2, fetches)
+
+	// Unknown keys are rejected
+	assert.EqualError(t, ks.Verify(ctx, sign(unknownSigner, "test")), `unknown signing key "key-3"`)
+	assert.Equal(t, 3, fetches)
+
+	// The key set is fetched again once the cache expires
+	assert.NoError(t, ks.Verify(ctx, sign(signer1, "test")))
+	assert.Equal(t, 3, fetches)
+	ks.Clock = api.FixedClock(now.Add(time.Hour))
+	assert.NoError(t, ks.Verify(ctx, sign(signer1, "test")))
+	assert.Equal(t, 4, fetches)
+}
+
+func TestConfig_JSONWebKeySetURL(t *testing.T) {
+	cfg := &Config{Issuer: "https://auth.example.com/"}
+	u, err := cfg.JSONWebKeySetURL()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "https://auth.example.com/.well-known/jwks.json", u)
+	}
+
+	_, err = (&Config{Issuer: "http://auth.example.com/"}).JSONWebKeySetURL()
+	assert.Error(t, err)
+}
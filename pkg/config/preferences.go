@@ -0,0 +1,129 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// OutputFormats is the list of supported output formats.
+var OutputFormats = []string{"json", "yaml", "ndjson"}
+
+// DefaultPollInterval is the polling interval used when there is no preference.
+const DefaultPollInterval = 30 * time.Second
+
+// Preferences are optional client-side settings which control command behavior.
+// Zero values indicate there is no preference.
+type Preferences struct {
+	// The output format to use when one is not explicitly requested.
+	OutputFormat string `json:"output,omitempty" yaml:"output,omitempty" env:"STORMFORGE_OUTPUT"`
+	// The interval used when polling for changes.
+	PollInterval time.Duration `json:"poll_interval,omitempty" yaml:"poll_interval,omitempty" env:"STORMFORGE_POLL_INTERVAL"`
+	// The number of items to request per page when fetching lists.
+	PageSize int `json:"page_size,omitempty" yaml:"page_size,omitempty" env:"STORMFORGE_PAGE_SIZE"`
+	// Explicitly enable or disable colored output.
+	Color *bool `json:"color,omitempty" yaml:"color,omitempty" env:"STORMFORGE_COLOR"`
+	// The number of decimal places used to display numeric trial assignments and values.
+	Precision *int `json:"precision,omitempty" yaml:"precision,omitempty" env:"STORMFORGE_PRECISION"`
+}
+
+// Merge overwrites these preferences with any preferences set on the supplied value.
+func (p *Preferences) Merge(other Preferences) {
+	if other.OutputFormat != "" {
+		p.OutputFormat = other.OutputFormat
+	}
+	if other.PollInterval != 0 {
+		p.PollInterval = other.PollInterval
+	}
+	if other.PageSize != 0 {
+		p.PageSize = other.PageSize
+	}
+	if other.Color != nil {
+		color := *other.Color
+		p.Color = &color
+	}
+	if other.Precision != nil {
+		precision := *other.Precision
+		p.Precision = &precision
+	}
+}
+
+// OutputFormat returns the preferred output format, if any.
+func (cfg *Config) OutputFormat() string {
+	return cfg.Preferences.OutputFormat
+}
+
+// PollInterval returns the preferred polling interval.
+func (cfg *Config) PollInterval() time.Duration {
+	if cfg.Preferences.PollInterval > 0 {
+		return cfg.Preferences.PollInterval
+	}
+	return DefaultPollInterval
+}
+
+// PageSize returns the preferred number of items per page, zero indicates the
+// server default should be used.
+func (cfg *Config) PageSize() int {
+	return cfg.Preferences.PageSize
+}
+
+// Color returns the preference for colored output, falling back to the supplied
+// value (e.g. based on terminal detection) when there is no preference.
+func (cfg *Config) Color(auto bool) bool {
+	if cfg.Preferences.Color != nil {
+		return *cfg.Preferences.Color
+	}
+	return auto
+}
+
+// Precision returns the preferred number of decimal places for displaying numbers,
+// a negative value indicates numbers should be displayed using full precision.
+func (cfg *Config) Precision() int {
+	if cfg.Preferences.Precision != nil {
+		return *cfg.Preferences.Precision
+	}
+	return -1
+}
+
+// validate checks the preferences for invalid values.
+func (p *Preferences) validate() error {
+	if p.PollInterval < 0 {
+		return fmt.Errorf("invalid poll interval preference %s, must not be negative", p.PollInterval)
+	}
+	if p.PageSize < 0 {
+		return fmt.Errorf("invalid page size preference %d, must not be negative", p.PageSize)
+	}
+	if p.Precision != nil && *p.Precision < 0 {
+		return fmt.Errorf("invalid precision preference %d, must not be negative", *p.Precision)
+	}
+	if p.OutputFormat != "" && !contains(OutputFormats, p.OutputFormat) {
+		return fmt.Errorf("invalid output format preference %q, must be one of: %s", p.OutputFormat, strings.Join(OutputFormats, ", "))
+	}
+	return nil
+}
+
+// contains checks for a string in a slice.
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/caarlos0/env/v6"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_Validate_OutputFormat(t *testing.T) {
+	cases := []struct {
+		desc   string
+		output string
+		page   string
+		err    bool
+	}{
+		{desc: "unset"},
+		{desc: "json", output: "json"},
+		{desc: "yaml", output: "yaml"},
+		{desc: "invalid", output: "xml", err: true},
+		{desc: "negative page size", page: "-1", err: true},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			t.Setenv("STORMFORGE_OUTPUT", c.output)
+			t.Setenv("STORMFORGE_PAGE_SIZE", c.page)
+			cfg := &Config{}
+			if !assert.NoError(t, env.Parse(cfg)) {
+				return
+			}
+
+			assert.Equal(t, c.output, cfg.OutputFormat())
+			if c.err {
+				assert.Error(t, cfg.Validate())
+			} else {
+				assert.NoError(t, cfg.Validate())
+			}
+		})
+	}
+}
+
+func TestPreferences_Merge(t *testing.T) {
+	enabled, disabled := true, false
+	cases := []struct {
+		desc     string
+		base     Preferences
+		other    Preferences
+		expected Preferences
+	}{
+		{
+			desc:     "empty",
+			base:     Preferences{OutputFormat: "json", PageSize: 10},
+			expected: Preferences{OutputFormat: "json", PageSize: 10},
+		},
+		{
+			desc:     "override",
+			base:     Preferences{OutputFormat: "json", PollInterval: time.Minute, Color: &enabled},
+			other:    Preferences{OutputFormat: "yaml", Color: &disabled},
+			expected: Preferences{OutputFormat: "yaml", PollInterval: time.Minute, Color: &disabled},
+		},
+		{
+			desc:     "fill",
+			other:    Preferences{PollInterval: time.Second, PageSize: 50},
+			expected: Preferences{PollInterval: time.Second, PageSize: 50},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			c.base.Merge(c.other)
+			assert.Equal(t, c.expected, c.base)
+		})
+	}
+}
+
+func TestConfig_Preferences_Defaults(t *testing.T) {
+	cfg := &Config{}
+	assert.Equal(t, "", cfg.OutputFormat())
+	assert.Equal(t, DefaultPollInterval, cfg.PollInterval())
+	assert.Equal(t, 0, cfg.PageSize())
+	assert.True(t, cfg.Color(true))
+	assert.False(t, cfg.Color(false))
+
+	disabled := false
+	cfg.Preferences = Preferences{PollInterval: 5 * time.Second, PageSize: 25, Color: &disabled}
+	assert.Equal(t, 5*time.Second, cfg.PollInterval())
+	assert.Equal(t, 25, cfg.PageSize())
+	assert.False(t, cfg.Color(true))
+}
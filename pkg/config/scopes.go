@@ -0,0 +1,77 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/oauth2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// GrantedScopes returns the scopes granted to the supplied access token. The token
+// is decoded without verifying the signature, it is an error if the access token
+// is not a JWT.
+func GrantedScopes(tok *oauth2.Token) ([]string, error) {
+	if tok == nil || tok.AccessToken == "" {
+		return nil, fmt.Errorf("missing access token")
+	}
+
+	accessToken, err := jwt.ParseSigned(tok.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := struct {
+		Scope string   `json:"scope"`
+		Scp   []string `json:"scp"`
+	}{}
+	if err := accessToken.UnsafeClaimsWithoutVerification(&claims); err != nil {
+		return nil, err
+	}
+
+	// Prefer the space delimited "scope" claim (RFC 8693), fall back to "scp"
+	if claims.Scope != "" {
+		return strings.Fields(claims.Scope), nil
+	}
+	return claims.Scp, nil
+}
+
+// MissingScopes returns the required scopes which have not been granted to the
+// supplied access token, in the order they were required. A re-authorization
+// request should add exactly these scopes to the scopes already granted.
+func MissingScopes(tok *oauth2.Token, required ...string) ([]string, error) {
+	granted, err := GrantedScopes(tok)
+	if err != nil {
+		return nil, err
+	}
+
+	has := make(map[string]bool, len(granted))
+	for _, s := range granted {
+		has[s] = true
+	}
+
+	var missing []string
+	for _, s := range required {
+		if !has[s] {
+			missing = append(missing, s)
+			has[s] = true
+		}
+	}
+	return missing, nil
+}
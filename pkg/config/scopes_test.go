@@ -0,0 +1,81 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestMissingScopes(t *testing.T) {
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: []byte("0123456789abcdef")}, nil)
+	require.NoError(t, err)
+
+	cases := []struct {
+		desc     string
+		claims   map[string]interface{}
+		token    string
+		required []string
+		expected []string
+		err      bool
+	}{
+		{
+			desc:     "fully covered",
+			claims:   map[string]interface{}{"scope": "read:experiments write:experiments"},
+			required: []string{"read:experiments", "write:experiments"},
+		},
+		{
+			desc:     "partially covered",
+			claims:   map[string]interface{}{"scope": "read:experiments"},
+			required: []string{"read:experiments", "write:experiments", "read:applications", "write:experiments"},
+			expected: []string{"write:experiments", "read:applications"},
+		},
+		{
+			desc:     "scp claim",
+			claims:   map[string]interface{}{"scp": []string{"read:experiments"}},
+			required: []string{"read:experiments", "write:experiments"},
+			expected: []string{"write:experiments"},
+		},
+		{
+			desc:     "non-JWT",
+			token:    "opaque-access-token",
+			required: []string{"read:experiments"},
+			err:      true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			tok := &oauth2.Token{AccessToken: c.token}
+			if c.claims != nil {
+				tok.AccessToken, err = jwt.Signed(signer).Claims(c.claims).CompactSerialize()
+				require.NoError(t, err)
+			}
+
+			missing, err := MissingScopes(tok, c.required...)
+			if c.err {
+				assert.Error(t, err)
+			} else if assert.NoError(t, err) {
+				assert.Equal(t, c.expected, missing)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"net/url"
+	"strings"
+)
+
+// joinURL appends path elements to the path of the base URL. The base path is always
+// treated as a directory (regardless of a trailing slash), the query and fragment of
+// the base are preserved, and the result only ends with a slash if the last element does.
+func joinURL(base string, elem ...string) (*url.URL, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return nil, err
+	}
+
+	p := u.Path
+	for _, e := range elem {
+		if e == "" {
+			continue
+		}
+		p = strings.TrimSuffix(p, "/") + "/" + strings.TrimPrefix(e, "/")
+	}
+	if p == "" {
+		p = "/"
+	}
+
+	u.Path = p
+	u.RawPath = ""
+	return u, nil
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2022 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJoinURL(t *testing.T) {
+	cases := []struct {
+		desc     string
+		base     string
+		elem     []string
+		expected string
+	}{
+		{
+			desc:     "trailing slash",
+			base:     "https://example.com/api/",
+			elem:     []string{"v1/experiments/"},
+			expected: "https://example.com/api/v1/experiments/",
+		},
+		{
+			desc:     "no trailing slash",
+			base:     "https://example.com/api",
+			elem:     []string{"v1/experiments/"},
+			expected: "https://example.com/api/v1/experiments/",
+		},
+		{
+			desc:     "no path",
+			base:     "https://example.com",
+			elem:     []string{"oauth/token"},
+			expected: "https://example.com/oauth/token",
+		},
+		{
+			desc:     "leading slashes",
+			base:     "https://example.com/tenant/",
+			elem:     []string{"/oauth/", "/token"},
+			expected: "https://example.com/tenant/oauth/token",
+		},
+		{
+			desc:     "query preserved",
+			base:     "https://example.com/api?tenant=a",
+			elem:     []string{"v2/applications/"},
+			expected: "https://example.com/api/v2/applications/?tenant=a",
+		},
+		{
+			desc:     "no elements",
+			base:     "https://example.com",
+			expected: "https://example.com/",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			u, err := joinURL(c.base, c.elem...)
+			if assert.NoError(t, err) {
+				assert.Equal(t, c.expected, u.String())
+			}
+		})
+	}
+}